@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// replCommand is a command handled by the client itself at the prompt
+type replCommand struct {
+	usage       string
+	description string
+	// tool is the server tool the command needs; empty when it works with
+	// any server
+	tool string
+}
+
+// replCommands lists the client commands in the order help shows them
+var replCommands = []replCommand{
+	{usage: "help", description: "show this help"},
+	{usage: "tools", description: "list tools and parameters"},
+	{usage: `/call <tool> {"arg": ...}`, description: "call a tool directly"},
+	{usage: "/products", description: "list every product, a page at a time", tool: "list_products"},
+	{usage: `/bench <n> <tool> {"arg": ...}`, description: "benchmark a tool across the -pool servers"},
+	{usage: "exit", description: "quit"},
+}
+
+// printCommands lists the client commands that work with the connected
+// server, leaving out those whose tool it does not offer
+func printCommands(w io.Writer, tools []openai.Tool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range replCommands {
+		if c.tool != "" && !hasTool(tools, c.tool) {
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", c.usage, c.description)
+	}
+	tw.Flush()
+}
+
+// printWelcomeBanner greets the user with what the connected server reports
+// about itself and the tools it actually offers, so the banner stays accurate
+// whichever server build the client talks to
+func printWelcomeBanner(w io.Writer, name, version string, tools []openai.Tool, canAsk bool) {
+	server := "the server"
+	if name != "" {
+		server = name
+		if version != "" {
+			server += " v" + version
+		}
+	}
+	fmt.Fprintf(w, "\nWelcome! Connected to %s.\n", server)
+
+	if len(tools) == 0 {
+		fmt.Fprintln(w, "The server does not offer any tools.")
+	} else {
+		if canAsk {
+			fmt.Fprintf(w, "Ask in plain language; the server has %d tools:\n", len(tools))
+		} else {
+			fmt.Fprintf(w, "The server has %d tools:\n", len(tools))
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, tool := range tools {
+			if tool.Function == nil {
+				continue
+			}
+			fmt.Fprintf(tw, "  %s\t%s\n", tool.Function.Name, firstLine(tool.Function.Description))
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintln(w, "\nCommands:")
+	printCommands(w, tools)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// cacheableTools lists the tools whose results depend only on their
+// arguments. Stateful tools such as place_order or earn_points are never
+// cached, so a repeated call always reaches the server.
+var cacheableTools = map[string]bool{
+	"get_price":            true,
+	"get_product_details":  true,
+	"compare_by_attribute": true,
+	"list_products":        true,
+	"resolve_alias":        true,
+	"calculate_total":      true,
+	"apply_discount":       true,
+	"apply_discounts":      true,
+	"parse_quantity":       true,
+	"shipping_cost":        true,
+	"split_bill":           true,
+	"help":                 true,
+}
+
+type cacheEntry struct {
+	response string
+	expires  time.Time
+}
+
+// toolCache keeps recent responses of deterministic tool calls for a short
+// time so that repeated questions do not hit the server again
+type toolCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+func newToolCache(ttl time.Duration) *toolCache {
+	return &toolCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// cacheKey builds the key for a call from the tool name and its arguments.
+// Map keys are marshaled in sorted order, so equal arguments give equal keys.
+func cacheKey(name string, arguments map[string]interface{}) (string, bool) {
+	if !cacheableTools[name] {
+		return "", false
+	}
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return "", false
+	}
+	return name + ":" + string(data), true
+}
+
+// Get returns the cached response for the key if it has not expired
+func (c *toolCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.response, true
+}
+
+// Put stores a response under the key
+func (c *toolCache) Put(key, response string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{response: response, expires: time.Now().Add(c.ttl)}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// confirmPrompt asks the user to approve an order before it is placed
+const confirmPrompt = "Confirm? (y/n): "
+
+// orderNotConfirmed is passed on as the tool result when the user turns an
+// order down, so the reply explains that nothing was bought
+const orderNotConfirmed = "The order was not placed because the user did not confirm it."
+
+// needsConfirmation reports whether a tool call would commit an order. Dry
+// runs only preview the order, so they go through without asking.
+func needsConfirmation(name string, arguments map[string]interface{}) bool {
+	if name != "place_order" {
+		return false
+	}
+	dryRun, _ := arguments["dry_run"].(bool)
+	return !dryRun
+}
+
+// confirmOrder previews the order the model asked for with a dry run, shows
+// the items and total and asks the user to confirm. Anything but y or yes,
+// including Ctrl-C or closed input, declines. A preview that fails is shown
+// and counts as declined, since the real order would fail the same way.
+func confirmOrder(server *MCPServer, rl *readline.Instance, w io.Writer, traceID string, arguments map[string]interface{}) bool {
+	preview := make(map[string]interface{}, len(arguments)+1)
+	for k, v := range arguments {
+		preview[k] = v
+	}
+	preview["dry_run"] = true
+
+	response, err := server.CallToolWithTrace(traceID, "place_order", preview)
+	if err != nil {
+		fmt.Fprintf(w, "[trace %s] Could not preview the order: %v\n", traceID, err)
+		return false
+	}
+	summary, err := parseStructuredResponse(response)
+	if err != nil {
+		fmt.Fprintf(w, "[trace %s] Could not parse the order preview: %v\n", traceID, err)
+		return false
+	}
+	if success, _ := summary["success"].(bool); !success {
+		fmt.Fprintf(w, "\n%v\n", summary["message"])
+		return false
+	}
+
+	fmt.Fprintln(w, "\nOrder summary:")
+	currency, _ := summary["currency"].(string)
+	items, _ := summary["items"].([]interface{})
+	for _, item := range items {
+		line, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "  %v x %v @ %s = %s\n", line["quantity"], line["product_name"], formatAmount(line["price"], ""), formatAmount(line["item_total"], ""))
+	}
+	fmt.Fprintf(w, "  Total: %s\n", formatAmount(summary["total_price"], currency))
+
+	// Keep the answer out of the question history
+	rl.HistoryDisable()
+	defer rl.HistoryEnable()
+	rl.SetPrompt(confirmPrompt)
+	defer rl.SetPrompt(replPrompt)
+
+	answer, err := rl.Readline()
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// formatAmount writes an amount from a tool result without a currency symbol,
+// since the symbol and its position depend on the server's currency and
+// locale. The currency code follows when one is given.
+func formatAmount(amount interface{}, currency string) string {
+	value, _ := amount.(float64)
+	text := fmt.Sprintf("%.2f", value)
+	if currency != "" {
+		text += " " + currency
+	}
+	return text
+}
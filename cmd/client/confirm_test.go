@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestNeedsConfirmation(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      string
+		arguments map[string]interface{}
+		want      bool
+	}{
+		{"order", "place_order", map[string]interface{}{}, true},
+		{"dry run", "place_order", map[string]interface{}{"dry_run": true}, false},
+		{"explicit real run", "place_order", map[string]interface{}{"dry_run": false}, true},
+		{"other tool", "calculate_total", map[string]interface{}{}, false},
+	}
+	for _, tt := range tests {
+		if got := needsConfirmation(tt.tool, tt.arguments); got != tt.want {
+			t.Errorf("%s: needsConfirmation = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAmountHasNoSymbol(t *testing.T) {
+	tests := []struct {
+		amount   interface{}
+		currency string
+		want     string
+	}{
+		{1500.0, "", "1500.00"},
+		{19.9, "EUR", "19.90 EUR"},
+		{nil, "", "0.00"},
+	}
+	for _, tt := range tests {
+		if got := formatAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("formatAmount(%v, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
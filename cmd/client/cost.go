@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Default prices in USD per million tokens, matching GPT-4 Turbo, the model
+// the client asks
+const (
+	defaultPromptTokenPrice     = 10.0
+	defaultCompletionTokenPrice = 30.0
+)
+
+// costWarnRatio is the share of the cost limit at which the client warns
+// that the budget is running out
+const costWarnRatio = 0.8
+
+// costTracker adds up the token usage reported by the OpenAI API over a
+// session and estimates what it cost. The estimate uses the configured
+// prices, so it is only as accurate as they are.
+type costTracker struct {
+	// promptPrice and completionPrice are in USD per million tokens
+	promptPrice     float64
+	completionPrice float64
+	// limit stops questions to the model once the estimated cost reaches
+	// it; 0 means no limit
+	limit float64
+
+	promptTokens     int
+	completionTokens int
+	warned           bool
+}
+
+func newCostTracker(promptPrice, completionPrice, limit float64) *costTracker {
+	return &costTracker{promptPrice: promptPrice, completionPrice: completionPrice, limit: limit}
+}
+
+// sessionCost tracks the OpenAI usage of the interactive session; nil when
+// the model is not used
+var sessionCost *costTracker
+
+// Record adds the usage of one API response
+func (c *costTracker) Record(usage openai.Usage) {
+	if c == nil {
+		return
+	}
+	c.promptTokens += usage.PromptTokens
+	c.completionTokens += usage.CompletionTokens
+}
+
+// Cost returns the estimated cost in USD of all usage recorded so far
+func (c *costTracker) Cost() float64 {
+	if c == nil {
+		return 0
+	}
+	return (float64(c.promptTokens)*c.promptPrice + float64(c.completionTokens)*c.completionPrice) / 1e6
+}
+
+// Exceeded reports whether the estimated cost has reached the limit
+func (c *costTracker) Exceeded() bool {
+	return c != nil && c.limit > 0 && c.Cost() >= c.limit
+}
+
+// Report prints the cost of the turn that started when the session had cost
+// turnStart, the running total, and a warning once the total nears or
+// reaches the limit
+func (c *costTracker) Report(w io.Writer, traceID string, turnStart float64) {
+	if c == nil {
+		return
+	}
+	total := c.Cost()
+	fmt.Fprintf(w, "[trace %s] Estimated OpenAI cost: $%.4f this turn, $%.4f this session (%d prompt + %d completion tokens)",
+		traceID, total-turnStart, total, c.promptTokens, c.completionTokens)
+	if c.limit > 0 {
+		fmt.Fprintf(w, ", limit $%.2f", c.limit)
+	}
+	fmt.Fprintln(w)
+
+	switch {
+	case c.Exceeded():
+		fmt.Fprintf(w, "The estimated cost has reached CLIENT_COST_LIMIT ($%.2f); questions to the model are disabled for the rest of this session. Direct tool commands still work.\n", c.limit)
+	case c.limit > 0 && total >= c.limit*costWarnRatio && !c.warned:
+		c.warned = true
+		fmt.Fprintf(w, "Warning: the estimated cost is over %.0f%% of CLIENT_COST_LIMIT ($%.2f)\n", costWarnRatio*100, c.limit)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// stderrTailSize is how much of the end of the server's stderr is kept
+	// for error reports
+	stderrTailSize = 4096
+	// exitWait is how long a closed connection waits for the server
+	// process to finish exiting before it is reported as still running
+	exitWait = time.Second
+)
+
+// stderrTail keeps the last stderrTailSize bytes written to it, so the
+// server's final log lines can be shown when it exits unexpectedly
+type stderrTail struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.data = append(t.data, p...)
+	if len(t.data) > stderrTailSize {
+		t.data = append([]byte(nil), t.data[len(t.data)-stderrTailSize:]...)
+	}
+	return len(p), nil
+}
+
+func (t *stderrTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return strings.TrimSpace(string(t.data))
+}
+
+// serverExitError reports that the server closed the connection while the
+// client was waiting for a response during phase, with the exit code and
+// stderr of the process when it has exited
+type serverExitError struct {
+	Phase    string
+	Exited   bool
+	ExitCode int
+	// Status describes an exit without a code, such as "signal: killed"
+	Status string
+	Stderr string
+	Err    error
+}
+
+func (e *serverExitError) Error() string {
+	var msg string
+	switch {
+	case e.Exited && e.Status != "":
+		msg = fmt.Sprintf("server exited during %s (%s)", e.Phase, e.Status)
+	case e.Exited:
+		msg = fmt.Sprintf("server exited during %s (exit code %d)", e.Phase, e.ExitCode)
+	default:
+		msg = fmt.Sprintf("server closed the connection during %s but is still running: %v", e.Phase, e.Err)
+	}
+	if e.Stderr != "" {
+		msg += "\nserver stderr:\n" + e.Stderr
+	}
+	return msg
+}
+
+func (e *serverExitError) Unwrap() error {
+	return e.Err
+}
+
+// wait records the exit of the server process; it runs once per server in
+// its own goroutine so that exits can be noticed without blocking Close
+func (s *MCPServer) wait() {
+	s.waitErr = s.cmd.Wait()
+	close(s.exited)
+}
+
+// describeClosed turns a lost connection seen during phase, whether the
+// server closed its output or stopped reading its input, into a
+// serverExitError carrying the exit code and stderr. Other errors, such as
+// timeouts, are returned unchanged.
+func (s *MCPServer) describeClosed(phase string, err error) error {
+	if !connectionLost(err) {
+		return err
+	}
+
+	exitErr := &serverExitError{Phase: phase, Err: err}
+	select {
+	case <-s.exited:
+		exitErr.Exited = true
+		exitErr.ExitCode = s.cmd.ProcessState.ExitCode()
+		if exitErr.ExitCode < 0 {
+			exitErr.Status = s.cmd.ProcessState.String()
+		}
+	case <-time.After(exitWait):
+	}
+	if s.stderr != nil {
+		exitErr.Stderr = s.stderr.String()
+	}
+	return exitErr
+}
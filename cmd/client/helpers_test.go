@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// newFakeServer connects an MCPServer to an in-process fake instead of a
+// server subprocess. respond receives every request and notification the
+// client writes, in order, and returns the lines to send back.
+func newFakeServer(t *testing.T, respond func(request map[string]interface{}) []string) *MCPServer {
+	t.Helper()
+	requestsOut, requestsIn := io.Pipe()
+	responsesOut, responsesIn := io.Pipe()
+
+	s := &MCPServer{
+		stdin:  requestsIn,
+		stdout: responsesOut,
+		reader: bufio.NewReader(responsesOut),
+		exited: make(chan struct{}),
+	}
+
+	// The fake "exits" once the client closes its input, as a real server does
+	go func() {
+		defer close(s.exited)
+		defer responsesIn.Close()
+		scanner := bufio.NewScanner(requestsOut)
+		for scanner.Scan() {
+			var request map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+				t.Errorf("client sent a line that is not JSON: %q", scanner.Text())
+				continue
+			}
+			for _, line := range respond(request) {
+				fmt.Fprintln(responsesIn, line)
+			}
+		}
+	}()
+	t.Cleanup(func() { requestsIn.Close() })
+
+	return s
+}
+
+// rpcResult encodes a JSON-RPC response line
+func rpcResult(id interface{}, result interface{}) string {
+	line, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+	return string(line)
+}
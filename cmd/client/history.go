@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/chzyer/readline"
+)
+
+// replPrompt is shown before every question
+const replPrompt = "Please enter your question: "
+
+// historyFile returns where the REPL keeps its history across sessions:
+// CLIENT_HISTORY_FILE when it is set, otherwise ~/.mcp_store_history. An
+// empty CLIENT_HISTORY_FILE keeps the history in memory only.
+func historyFile() string {
+	if path, ok := os.LookupEnv("CLIENT_HISTORY_FILE"); ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mcp_store_history")
+}
+
+// newLineReader returns a line editor for the REPL with up-arrow history,
+// Ctrl-R search and cursor movement that handles wide Chinese characters
+func newLineReader() (*readline.Instance, error) {
+	return readline.NewEx(&readline.Config{
+		Prompt:            replPrompt,
+		HistoryFile:       historyFile(),
+		HistorySearchFold: true,
+		InterruptPrompt:   "^C",
+		EOFPrompt:         "exit",
+	})
+}
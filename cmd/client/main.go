@@ -3,23 +3,63 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/chzyer/readline"
 	openai "github.com/sashabaranov/go-openai"
 )
 
-// MCPServer represents a connection to the MCP server
+// defaultSystemPrompt is used when the server does not provide the
+// shopping_assistant prompt
+const defaultSystemPrompt = `You are a shopping assistant. Use the available tools to answer the user's questions about products, prices and discounts.`
+
+// MCPServer represents a connection to the MCP server.
+//
+// Requests share a single stdin/stdout pipe, so each method holds mu while it
+// writes its request and reads the matching response. Concurrent callers are
+// therefore safe but serialized; use several connections for parallelism.
 type MCPServer struct {
-	cmd     *exec.Cmd
-	stdin   io.WriteCloser
-	stdout  io.ReadCloser
-	scanner *bufio.Scanner
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	reader *bufio.Reader
+
+	// readTimeout bounds each response read; partial holds the start of a
+	// line cut off by a timeout and abandoned counts responses still owed
+	// for requests that timed out
+	readTimeout time.Duration
+	partial     []byte
+	abandoned   int
+
+	// serverName and serverVersion come from the initialize response
+	serverName    string
+	serverVersion string
+
+	// capabilities advertised by the server in its initialize response
+	hasTools     bool
+	hasResources bool
+	hasPrompts   bool
+
+	// traceOut receives a copy of raw tool call traffic in verbose mode
+	traceOut io.Writer
+
+	// stderr keeps the end of the server's stderr; exited is closed once the
+	// process has exited, with the result of Wait in waitErr
+	stderr  *stderrTail
+	exited  chan struct{}
+	waitErr error
 }
 
 // NewMCPServer creates a new connection to the MCP server
@@ -35,17 +75,24 @@ func NewMCPServer() (*MCPServer, error) {
 		return nil, fmt.Errorf("failed to get stdout pipe: %v", err)
 	}
 
+	stderr := &stderrTail{}
+	cmd.Stderr = stderr
+
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start server: %v", err)
 	}
 
-	scanner := bufio.NewScanner(stdout)
-	return &MCPServer{
-		cmd:     cmd,
-		stdin:   stdin,
-		stdout:  stdout,
-		scanner: scanner,
-	}, nil
+	s := &MCPServer{
+		cmd:         cmd,
+		stdin:       stdin,
+		stdout:      stdout,
+		reader:      bufio.NewReader(stdout),
+		readTimeout: defaultReadTimeout,
+		stderr:      stderr,
+		exited:      make(chan struct{}),
+	}
+	go s.wait()
+	return s, nil
 }
 
 // Close closes the connection to the server
@@ -53,11 +100,15 @@ func (s *MCPServer) Close() error {
 	if err := s.stdin.Close(); err != nil {
 		return fmt.Errorf("failed to close stdin: %v", err)
 	}
-	return s.cmd.Wait()
+	<-s.exited
+	return s.waitErr
 }
 
 // Initialize sends the initialization request to the MCP server
 func (s *MCPServer) Initialize() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	initRequest := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      1,
@@ -74,149 +125,529 @@ func (s *MCPServer) Initialize() error {
 
 	reqBytes, _ := json.Marshal(initRequest)
 	if _, err := fmt.Fprintf(s.stdin, "%s\n", reqBytes); err != nil {
-		return fmt.Errorf("failed to send initialization request: %v", err)
+		return fmt.Errorf("failed to send initialization request: %w", s.describeClosed("initialize", err))
 	}
 
-	if s.scanner.Scan() {
-		responseText := s.scanner.Text()
+	responseText, err := s.readResponse()
+	if err != nil {
+		return fmt.Errorf("failed to read initialization response: %w", s.describeClosed("initialize", err))
+	}
 
-		// Parse the initialization response
-		var response map[string]interface{}
-		if err := json.Unmarshal([]byte(responseText), &response); err != nil {
-			return fmt.Errorf("failed to parse initialization response: %v", err)
+	// Parse the initialization response
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(responseText), &response); err != nil {
+		return fmt.Errorf("failed to parse initialization response: %v", err)
+	}
+
+	// Check if initialization was successful
+	if result, ok := response["result"].(map[string]interface{}); ok {
+		if serverInfo, ok := result["serverInfo"].(map[string]interface{}); ok {
+			s.serverName, _ = serverInfo["name"].(string)
+			s.serverVersion, _ = serverInfo["version"].(string)
 		}
 
-		// Check if initialization was successful
-		if result, ok := response["result"].(map[string]interface{}); ok {
-			if serverInfo, ok := result["serverInfo"].(map[string]interface{}); ok {
-				fmt.Printf("Connected to: %s v%s\n", serverInfo["name"], serverInfo["version"])
-			}
+		// Record which features the server supports
+		if capabilities, ok := result["capabilities"].(map[string]interface{}); ok {
+			_, s.hasTools = capabilities["tools"]
+			_, s.hasResources = capabilities["resources"]
+			_, s.hasPrompts = capabilities["prompts"]
 		}
-		return nil
 	}
-	return fmt.Errorf("failed to initialize server")
+
+	// The spec requires the client to confirm initialization before any
+	// further requests; notifications carry no id and get no response
+	initializedNotification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/initialized",
+	}
+	notificationBytes, _ := json.Marshal(initializedNotification)
+	if _, err := fmt.Fprintf(s.stdin, "%s\n", notificationBytes); err != nil {
+		return fmt.Errorf("failed to send initialized notification: %v", err)
+	}
+	return nil
+}
+
+// ServerInfo returns the name and version the server reported when it was
+// initialized
+func (s *MCPServer) ServerInfo() (string, string) {
+	return s.serverName, s.serverVersion
+}
+
+// HasToolsCapability reports whether the server advertised tool support
+func (s *MCPServer) HasToolsCapability() bool {
+	return s.hasTools
+}
+
+// HasResourcesCapability reports whether the server advertised resource support
+func (s *MCPServer) HasResourcesCapability() bool {
+	return s.hasResources
+}
+
+// HasPromptsCapability reports whether the server advertised prompt support
+func (s *MCPServer) HasPromptsCapability() bool {
+	return s.hasPrompts
 }
 
-// ListTools retrieves the list of available tools from the MCP server
+// ListTools retrieves the list of available tools from the MCP server,
+// following nextCursor until every page has been fetched
 func (s *MCPServer) ListTools() ([]openai.Tool, error) {
+	var openaiTools []openai.Tool
+	cursor := ""
+
+	for {
+		tools, nextCursor, err := s.listToolsPage(cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tool := range tools {
+			toolMap, ok := tool.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name, _ := toolMap["name"].(string)
+			description, _ := toolMap["description"].(string)
+			inputSchema, _ := toolMap["inputSchema"].(map[string]interface{})
+
+			openaiTool := openai.Tool{
+				Type: "function",
+				Function: &openai.FunctionDefinition{
+					Name:        name,
+					Description: description,
+					Parameters:  inputSchema,
+				},
+			}
+			openaiTools = append(openaiTools, openaiTool)
+		}
+
+		if nextCursor == "" {
+			return openaiTools, nil
+		}
+		if nextCursor == cursor {
+			return nil, fmt.Errorf("server returned the same cursor %q twice", cursor)
+		}
+		cursor = nextCursor
+	}
+}
+
+// listToolsPage requests a single page of tools starting at cursor and
+// returns the raw tool entries and the cursor of the next page, if any
+func (s *MCPServer) listToolsPage(cursor string) ([]interface{}, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	listToolsRequest := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      2,
 		"method":  "tools/list",
 	}
+	if cursor != "" {
+		listToolsRequest["params"] = map[string]interface{}{"cursor": cursor}
+	}
 
 	reqBytes, _ := json.Marshal(listToolsRequest)
 	if _, err := fmt.Fprintf(s.stdin, "%s\n", reqBytes); err != nil {
-		return nil, fmt.Errorf("failed to send tools list request: %v", err)
+		return nil, "", fmt.Errorf("failed to send tools list request: %w", s.describeClosed("tools/list", err))
 	}
 
-	if !s.scanner.Scan() {
-		return nil, fmt.Errorf("failed to get tools list")
+	responseText, err := s.readResponse()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get tools list: %w", s.describeClosed("tools/list", err))
 	}
 
-	responseText := s.scanner.Text()
-
 	var response map[string]interface{}
 	if err := json.Unmarshal([]byte(responseText), &response); err != nil {
-		return nil, fmt.Errorf("failed to parse tools list response: %v", err)
+		return nil, "", fmt.Errorf("failed to parse tools list response: %v", err)
 	}
 
 	result, ok := response["result"].(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("invalid response format - no result field")
+		return nil, "", fmt.Errorf("invalid response format - no result field")
 	}
 
 	tools, ok := result["tools"].([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("invalid tools format - tools field not found or not an array")
+		return nil, "", fmt.Errorf("invalid tools format - tools field not found or not an array")
 	}
 
-	var openaiTools []openai.Tool
-	for _, tool := range tools {
-		toolMap, ok := tool.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		name, _ := toolMap["name"].(string)
-		description, _ := toolMap["description"].(string)
-		inputSchema, _ := toolMap["inputSchema"].(map[string]interface{})
-
-		openaiTool := openai.Tool{
-			Type: "function",
-			Function: &openai.FunctionDefinition{
-				Name:        name,
-				Description: description,
-				Parameters:  inputSchema,
-			},
-		}
-		openaiTools = append(openaiTools, openaiTool)
-	}
-
-	return openaiTools, nil
+	nextCursor, _ := result["nextCursor"].(string)
+	return tools, nextCursor, nil
 }
 
 // CallTool sends a tool call request to the MCP server
 func (s *MCPServer) CallTool(name string, arguments map[string]interface{}) (string, error) {
+	return s.CallToolWithTrace("", name, arguments)
+}
+
+// CallToolWithTrace sends a tool call request carrying the given trace ID in
+// the request's _meta so server logs can be correlated with the client turn
+func (s *MCPServer) CallToolWithTrace(traceID, name string, arguments map[string]interface{}) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	params := map[string]interface{}{
+		"name":      name,
+		"arguments": arguments,
+	}
+	if traceID != "" {
+		params["_meta"] = map[string]interface{}{"trace_id": traceID}
+	}
+
 	toolRequest := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      3,
 		"method":  "tools/call",
+		"params":  params,
+	}
+
+	reqBytes, _ := json.Marshal(toolRequest)
+	s.traceLine("-->", string(reqBytes))
+	if _, err := fmt.Fprintf(s.stdin, "%s\n", reqBytes); err != nil {
+		return "", fmt.Errorf("failed to send tool call request: %w", err)
+	}
+
+	response, err := s.readResponse()
+	if err != nil {
+		return "", fmt.Errorf("failed to get response: %w", err)
+	}
+	s.traceLine("<--", response)
+	return response, nil
+}
+
+// GetPrompt retrieves a prompt from the MCP server and returns its text content
+func (s *MCPServer) GetPrompt(name string, arguments map[string]string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	promptRequest := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      4,
+		"method":  "prompts/get",
 		"params": map[string]interface{}{
 			"name":      name,
 			"arguments": arguments,
 		},
 	}
 
-	reqBytes, _ := json.Marshal(toolRequest)
+	reqBytes, _ := json.Marshal(promptRequest)
 	if _, err := fmt.Fprintf(s.stdin, "%s\n", reqBytes); err != nil {
-		return "", fmt.Errorf("failed to send tool call request: %v", err)
+		return "", fmt.Errorf("failed to send prompt request: %v", err)
+	}
+
+	responseText, err := s.readResponse()
+	if err != nil {
+		return "", fmt.Errorf("failed to get prompt: %w", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(responseText), &response); err != nil {
+		return "", fmt.Errorf("failed to parse prompt response: %v", err)
 	}
 
-	if s.scanner.Scan() {
-		return s.scanner.Text(), nil
+	if errObj, ok := response["error"].(map[string]interface{}); ok {
+		return "", fmt.Errorf("server error: %v", errObj["message"])
 	}
-	return "", fmt.Errorf("failed to get response")
+
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("invalid response format - no result field")
+	}
+
+	messages, ok := result["messages"].([]interface{})
+	if !ok {
+		return "", fmt.Errorf("invalid prompt format - messages field not found or not an array")
+	}
+
+	var parts []string
+	for _, message := range messages {
+		messageMap, ok := message.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if content, ok := messageMap["content"].(map[string]interface{}); ok {
+			if text, ok := content["text"].(string); ok {
+				parts = append(parts, text)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("prompt %s has no text content", name)
+	}
+
+	return strings.Join(parts, "\n\n"), nil
 }
 
-// extractContentFromResponse extracts the text content from a JSON response
-func extractContentFromResponse(response string) string {
-	var result map[string]interface{}
+// newTraceID generates a random ID identifying one interactive turn
+func newTraceID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// extractTextContents returns the text of every text content block in a
+// JSON-RPC response, in order
+func extractTextContents(response string) []string {
+	var result struct {
+		Result struct {
+			Content []map[string]interface{} `json:"content"`
+		} `json:"result"`
+	}
 	if err := json.Unmarshal([]byte(response), &result); err != nil {
+		return nil
+	}
+
+	var texts []string
+	for _, content := range result.Result.Content {
+		if text, ok := content["text"].(string); ok {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// extractContentFromResponse extracts the text content from a JSON response.
+// A tool may return several text blocks, such as a summary and a detail
+// block, so all of them are joined with newlines. A response without text
+// content is returned unchanged.
+func extractContentFromResponse(response string) string {
+	texts := extractTextContents(response)
+	if len(texts) == 0 {
 		return response
 	}
+	return strings.Join(texts, "\n")
+}
 
-	if resultObj, ok := result["result"].(map[string]interface{}); ok {
-		if contentArray, ok := resultObj["content"].([]interface{}); ok {
-			for _, content := range contentArray {
-				if contentMap, ok := content.(map[string]interface{}); ok {
-					if text, ok := contentMap["text"].(string); ok {
-						return text
-					}
-				}
-			}
+// extractStructuredContent returns the data of the first embedded
+// application/json resource in a tool call response
+func extractStructuredContent(response string) (map[string]interface{}, bool) {
+	var result struct {
+		Result struct {
+			Content []struct {
+				Type     string `json:"type"`
+				Resource struct {
+					MIMEType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"resource"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(response), &result); err != nil {
+		return nil, false
+	}
+
+	for _, content := range result.Result.Content {
+		if content.Type != "resource" || content.Resource.MIMEType != "application/json" {
+			continue
+		}
+		var structured map[string]interface{}
+		if err := json.Unmarshal([]byte(content.Resource.Text), &structured); err == nil {
+			return structured, true
 		}
 	}
-	return response
+	return nil, false
 }
 
 // parseStructuredResponse parses structured JSON response from MCP server
 func parseStructuredResponse(response string) (map[string]interface{}, error) {
-	content := extractContentFromResponse(response)
+	// Prefer the typed JSON resource; older servers only send text
+	if structured, ok := extractStructuredContent(response); ok {
+		checkSchemaVersion(structured)
+		return structured, nil
+	}
+
+	// Otherwise use the first text block holding a JSON object; a response
+	// without text content is parsed as is
+	texts := extractTextContents(response)
+	if len(texts) == 0 {
+		texts = []string{response}
+	}
+	for _, text := range texts {
+		var structuredData map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &structuredData); err == nil {
+			checkSchemaVersion(structuredData)
+			return structuredData, nil
+		}
+	}
+
+	// If it's not JSON, return all of the content as message
+	return map[string]interface{}{
+		"success": true,
+		"message": strings.Join(texts, "\n"),
+	}, nil
+}
+
+// polishSystemPrompt instructs the model to turn raw tool output into a
+// friendly reply
+const polishSystemPrompt = `You are a friendly store assistant. Please convert the system's response into a more friendly and natural conversation format.
+If the response is an error message, please tell the user about the problem in a more friendly way and provide suggestions.
+Please maintain a professional but friendly tone and respond in Traditional Chinese.
+If the response includes discount calculations, please clearly explain the original price and the discounted price.`
+
+// defaultOpenAITimeout bounds each OpenAI API call
+const defaultOpenAITimeout = 60 * time.Second
+
+// openAITimeout is the deadline of each OpenAI API call; 0 waits forever
+var openAITimeout = defaultOpenAITimeout
+
+// openAIContext returns the context for a single OpenAI API call
+func openAIContext() (context.Context, context.CancelFunc) {
+	if openAITimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), openAITimeout)
+}
+
+// describeOpenAIError turns an OpenAI API error into a message for the user,
+// explaining timeouts so the user knows the question can simply be retried
+func describeOpenAIError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("OpenAI API did not respond within %v (OPENAI_TIMEOUT), please ask again", openAITimeout)
+	}
+	return fmt.Sprintf("OpenAI API error: %v", err)
+}
+
+// polishResponse asks the model to rewrite the tool result for the user and
+// prints the reply as it streams in. If the stream fails before any text has
+// been printed it falls back to a regular completion. It returns the text
+// that was shown to the user.
+func polishResponse(client *openai.Client, input, lastResult string) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Model: openai.GPT4TurboPreview,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: polishSystemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("User question: %s\nSystem response: %s", input, lastResult),
+			},
+		},
+	}
+
+	ctx, cancel := openAIContext()
+	text, err := streamCompletion(ctx, client, req)
+	cancel()
+	if err == nil {
+		return text, nil
+	}
+	if text != "" {
+		// Part of the reply is already on screen, so retrying would repeat it
+		fmt.Println()
+		return text, fmt.Errorf("stream interrupted: %w", err)
+	}
+
+	ctx, cancel = openAIContext()
+	defer cancel()
+	resp, err := client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	sessionCost.Record(resp.Usage)
+	fmt.Printf("\n%s\n", resp.Choices[0].Message.Content)
+	return resp.Choices[0].Message.Content, nil
+}
+
+// streamCompletion prints a chat completion token by token and returns the
+// text printed so far, so callers know whether a retry is safe
+func streamCompletion(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest) (string, error) {
+	req.Stream = true
+	// Ask for the usage of the whole reply in a final chunk
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
 
-	var structuredData map[string]interface{}
-	if err := json.Unmarshal([]byte(content), &structuredData); err != nil {
-		// If it's not JSON, return the content as message
-		return map[string]interface{}{
-			"success": true,
-			"message": content,
-		}, nil
+	var text strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			if text.Len() > 0 {
+				fmt.Println()
+			}
+			return text.String(), nil
+		}
+		if err != nil {
+			return text.String(), err
+		}
+		if resp.Usage != nil {
+			sessionCost.Record(*resp.Usage)
+		}
+		if len(resp.Choices) == 0 || resp.Choices[0].Delta.Content == "" {
+			continue
+		}
+		if text.Len() == 0 {
+			fmt.Println()
+		}
+		fmt.Print(resp.Choices[0].Delta.Content)
+		text.WriteString(resp.Choices[0].Delta.Content)
 	}
+}
 
-	return structuredData, nil
+// hasTool reports whether the server offers a tool with the given name
+func hasTool(tools []openai.Tool, name string) bool {
+	for _, tool := range tools {
+		if tool.Function != nil && tool.Function.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkToolRoundTrip calls the ping tool and verifies that it answers pong
+func checkToolRoundTrip(server *MCPServer) error {
+	start := time.Now()
+	response, err := server.CallTool("ping", map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	result, err := parseStructuredResponse(response)
+	if err != nil {
+		return err
+	}
+	if pong, _ := result["pong"].(bool); !pong {
+		return fmt.Errorf("unexpected ping response: %s", extractContentFromResponse(response))
+	}
+	fmt.Printf("Tool round trip OK in %v (server time %v)\n", time.Since(start), result["server_time"])
+	return nil
+}
+
+// buildMessages assembles the tool-selection request: the system prompt, the
+// remembered conversation and the new question
+func buildMessages(systemPrompt string, history []openai.ChatCompletionMessage, input string) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(history)+2)
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: systemPrompt,
+	})
+	messages = append(messages, history...)
+	return append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: input,
+	})
 }
 
 func main() {
+	cacheEnabled := flag.Bool("cache", false, "cache results of deterministic tool calls")
+	cacheTTL := flag.Duration("cache-ttl", 30*time.Second, "how long cached tool results stay valid")
+	retryToolCalls := flag.Bool("retry-tool-calls", true, "retry once with a stronger instruction when the model answers a store question without calling a tool")
+	noPolish := flag.Bool("no-polish", false, "print the tool result message as is instead of asking the model to rewrite it")
+	poolSize := flag.Int("pool", 0, "start this many extra server processes for parallel /bench calls; 0 disables /bench")
+	assumeYes := flag.Bool("yes", false, "place orders without asking for confirmation, e.g. when scripting")
+	verbose := flag.Bool("verbose", false, "print each requested tool call with its raw JSON-RPC request, response and parsed result")
+	flag.Parse()
+
+	// Load configuration from .env; the server subprocess inherits it too
+	if err := loadDotEnv(".env"); err != nil {
+		fmt.Printf("Failed to load .env: %v\n", err)
+		return
+	}
+
 	// Connect to MCP server
 	server, err := NewMCPServer()
 	if err != nil {
@@ -224,6 +655,10 @@ func main() {
 		return
 	}
 	defer server.Close()
+	server.SetReadTimeout(envDuration("CLIENT_READ_TIMEOUT", defaultReadTimeout))
+	if *verbose {
+		server.SetTraceOutput(os.Stdout)
+	}
 
 	// Initialize server connection
 	if err := server.Initialize(); err != nil {
@@ -231,115 +666,232 @@ func main() {
 		return
 	}
 
-	// Get tools list from server
-	tools, err := server.ListTools()
-	if err != nil {
-		fmt.Printf("Failed to get tools list: %v\n", err)
-		return
+	// Extra servers for parallel benchmarking with /bench
+	var pool *ServerPool
+	if *poolSize > 0 {
+		readTimeout := envDuration("CLIENT_READ_TIMEOUT", defaultReadTimeout)
+		pool, err = NewServerPool(*poolSize, func() (*MCPServer, error) {
+			member, err := NewMCPServer()
+			if err != nil {
+				return nil, err
+			}
+			member.SetReadTimeout(readTimeout)
+			if err := member.Initialize(); err != nil {
+				member.Close()
+				return nil, err
+			}
+			return member, nil
+		})
+		if err != nil {
+			fmt.Printf("Failed to start server pool: %v\n", err)
+			return
+		}
+		defer pool.Close()
+		fmt.Printf("Started a pool of %d servers for /bench\n", pool.Size())
 	}
-	fmt.Println("\nAvailable tools:")
-	for _, tool := range tools {
-		fmt.Printf("- %s: %s\n", tool.Function.Name, tool.Function.Description)
+
+	// Get tools list from server, if it offers any
+	var tools []openai.Tool
+	if server.HasToolsCapability() {
+		tools, err = server.ListTools()
+		if err != nil {
+			fmt.Printf("Failed to get tools list: %v\n", err)
+			return
+		}
+		// Confirm a tool call makes the round trip before involving the model
+		if hasTool(tools, "ping") {
+			if err := checkToolRoundTrip(server); err != nil {
+				fmt.Printf("Tool round trip failed: %v\n", err)
+				return
+			}
+		}
+	} else {
+		fmt.Println("\nServer does not advertise any tools")
+	}
+
+	// Input schemas let sloppy argument types from the model be fixed
+	// before they reach the server
+	inputSchemas := toolInputSchemas(tools)
+
+	// Get the shopping assistant system prompt from server
+	systemPrompt := defaultSystemPrompt
+	if server.HasPromptsCapability() {
+		systemPrompt, err = server.GetPrompt("shopping_assistant", nil)
+		if err != nil {
+			fmt.Printf("Failed to get system prompt: %v\n", err)
+			return
+		}
+	} else {
+		fmt.Println("Server does not advertise prompts, using the built-in system prompt")
+	}
+
+	// Without an OpenAI API key only the commands that talk to the server
+	// directly are available
+	var client *openai.Client
+	if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
+		openAITimeout = envDuration("OPENAI_TIMEOUT", defaultOpenAITimeout)
+		config := openai.DefaultConfig(apiKey)
+		if baseURL := os.Getenv("OPENAI_BASE_URL"); baseURL != "" {
+			// Refuse a bad URL rather than sending the key to the default endpoint
+			if err := validateBaseURL(baseURL); err != nil {
+				fmt.Printf("Invalid OPENAI_BASE_URL %q: %v\n", baseURL, err)
+				return
+			}
+			config.BaseURL = strings.TrimRight(baseURL, "/")
+			fmt.Printf("Using OpenAI-compatible API at %s\n", config.BaseURL)
+		}
+		client = openai.NewClientWithConfig(config)
+
+		// Estimate what the session costs from the usage the API reports
+		sessionCost = newCostTracker(
+			envFloat("CLIENT_PROMPT_TOKEN_PRICE", defaultPromptTokenPrice),
+			envFloat("CLIENT_COMPLETION_TOKEN_PRICE", defaultCompletionTokenPrice),
+			envFloat("CLIENT_COST_LIMIT", 0),
+		)
+	} else {
+		printDirectModeBanner(os.Stdout)
 	}
 
-	// Check OpenAI API key
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		fmt.Println("Please set the OPENAI_API_KEY environment variable to continue with interactive mode")
+	// Interactive conversation, with line editing and history kept across
+	// sessions
+	lineReader, err := newLineReader()
+	if err != nil {
+		fmt.Printf("Failed to set up line editing: %v\n", err)
 		return
 	}
+	defer lineReader.Close()
+	name, version := server.ServerInfo()
+	printWelcomeBanner(os.Stdout, name, version, tools, client != nil)
+
+	// Remember recent turns so follow-up questions keep their context
+	memory := newConversationMemory(envInt("CLIENT_HISTORY_TURNS", 5), envInt("CLIENT_HISTORY_TOKENS", 2000))
 
-	// Initialize OpenAI client
-	client := openai.NewClient(apiKey)
+	// Cap the tool calls run per question so a misbehaving model cannot
+	// flood the server; 0 disables the cap
+	maxToolCalls := envInt("CLIENT_MAX_TOOL_CALLS", 10)
 
-	// Interactive conversation
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("\nWelcome to the Interactive Product Query System!")
-	fmt.Println("You can ask about product prices, calculate totals, or apply discounts.")
-	fmt.Println("Type 'exit' to quit.")
-	fmt.Println("Type 'help' for supported operations.")
+	var cache *toolCache
+	if *cacheEnabled {
+		cache = newToolCache(*cacheTTL)
+	}
+	polished := newPolishCache()
 
 	for {
-		fmt.Print("\nPlease enter your question: ")
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
+		fmt.Println()
+		line, err := lineReader.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			// Ctrl-C discards the current line
+			continue
+		}
+		input := strings.TrimSpace(line)
+		if err != nil && input == "" {
+			// Ctrl-D, or stdin was closed, e.g. when input is piped in
+			break
+		}
 
 		if input == "exit" {
 			break
 		}
+		if input == "" {
+			continue
+		}
+
+		// Show the server's help, or one derived from the tool list when the
+		// server has no help tool
+		if input == "help" {
+			runHelpCommand(server, os.Stdout, tools)
+			continue
+		}
+
+		// Print the live tool list without involving the model or the server
+		if input == "tools" {
+			printTools(os.Stdout, tools)
+			continue
+		}
+
+		// Call a tool directly, bypassing the model
+		if input == "/call" || strings.HasPrefix(input, "/call ") {
+			runCallCommand(server, os.Stdout, input)
+			continue
+		}
+
+		// Page through the whole catalog
+		if input == "/products" {
+			if !hasTool(tools, "list_products") {
+				fmt.Println("The server has no list_products tool")
+				continue
+			}
+			runProductsCommand(server, os.Stdout)
+			continue
+		}
+
+		// Benchmark a tool call across the server pool
+		if input == "/bench" || strings.HasPrefix(input, "/bench ") {
+			runBenchCommand(pool, os.Stdout, input)
+			continue
+		}
+
+		if client == nil {
+			// In direct tool mode "<tool> [json arguments]" is short for /call
+			if hasTool(tools, firstWord(input)) {
+				runCallCommand(server, os.Stdout, "/call "+input)
+				continue
+			}
+			fmt.Println(`Direct tool mode: use '<tool> {"arg": ...}' or '/call <tool> {"arg": ...}', or set OPENAI_API_KEY to ask questions`)
+			continue
+		}
+
+		// Stop asking the model once the session's budget is spent
+		if sessionCost.Exceeded() {
+			fmt.Printf("The estimated OpenAI cost has reached CLIENT_COST_LIMIT ($%.2f). Use '/call <tool> {\"arg\": ...}' to call tools directly, or restart the client.\n", sessionCost.limit)
+			continue
+		}
+
+		// Every tool call made for this question shares one trace ID
+		traceID := newTraceID()
+		turnStart := sessionCost.Cost()
 
 		// Use OpenAI to parse user input
+		req := openai.ChatCompletionRequest{
+			Model:    openai.GPT4TurboPreview,
+			Messages: buildMessages(systemPrompt, memory.Messages(), input),
+			Tools:    tools,
+		}
 		start := time.Now()
-		resp, err := client.CreateChatCompletion(
-			context.Background(),
-			openai.ChatCompletionRequest{
-				Model: openai.GPT4TurboPreview,
-				Messages: []openai.ChatCompletionMessage{
-					{
-						Role: openai.ChatMessageRoleSystem,
-						Content: `你是一個智能購物助手，專門處理複雜的中文購物查詢。你能夠理解中文表達並將其轉換為正確的工具調用。
-
-## 商品對應表
-- 筆電/筆記型電腦/電腦/laptop → product_id: "1" (價格: $1000)
-- 智慧型手機/手機/smartphone → product_id: "2" (價格: $500)  
-- 平板/平板電腦/tablet → product_id: "3" (價格: $300)
-
-## 中文數字轉換
-- 一/1 → 1, 二/2 → 2, 三/3 → 3, 四/4 → 4, 五/5 → 5
-- 六/6 → 6, 七/7 → 7, 八/8 → 8, 九/9 → 9, 十/10 → 10
-- 二十/20 → 20, 三十/30 → 30, 四十/40 → 40, 五十/50 → 50
-- 其他數字：直接使用阿拉伯數字
-
-## 折扣處理
-- "打X折" = discount_percentage: X
-- 例如：打三折 = 30, 打八折 = 80, 打五折 = 50
-
-## 工具使用規則
-
-### 1. 簡單價格查詢
-用戶問："筆電多少錢？" → 使用 get_price
-參數：{"product_id": "1"}
-
-### 2. 多商品總價計算  
-用戶問："五台筆電加上三台智慧型手機多少錢？" → 使用 calculate_total
-參數：{"items": [{"product_id": "1", "quantity": 5}, {"product_id": "2", "quantity": 3}]}
-
-### 3. 折扣應用
-用戶問："$2000打八折是多少？" → 使用 apply_discount
-參數：{"total_price": 2000, "discount_percentage": 80}
-
-### 4. 複合查詢（重要！）
-用戶問："五台筆電加上三十台智慧型手機再打三折"
-需要按順序調用：
-1. calculate_total: {"items": [{"product_id": "1", "quantity": 5}, {"product_id": "2", "quantity": 30}]}
-2. apply_discount: {"total_price": [從第一步結果中提取], "discount_percentage": 30}
-
-## 參數提取注意事項
-- product_id 必須是字符串 "1", "2", "3"
-- quantity 必須是正整數
-- discount_percentage 必須是 1-99 之間的數字
-- total_price 必須是正數
-
-## 錯誤處理
-如果無法完全理解用戶查詢，嘗試部分解析並說明需要更多資訊。
-
-請根據用戶的中文查詢選擇合適的工具並正確提取參數。`,
-					},
-					{
-						Role:    openai.ChatMessageRoleUser,
-						Content: input,
-					},
-				},
-				Tools: tools,
-			},
-		)
+		ctx, cancel := openAIContext()
+		resp, err := client.CreateChatCompletion(ctx, req)
+		cancel()
 		elapsed := time.Since(start)
-		fmt.Printf("OpenAI API response time: %v\n", elapsed)
+		fmt.Printf("[trace %s] OpenAI API response time: %v\n", traceID, elapsed)
 
 		if err != nil {
-			fmt.Printf("OpenAI API error: %v\n", err)
+			fmt.Println(describeOpenAIError(err))
 			continue
 		}
+		sessionCost.Record(resp.Usage)
+
+		// The model answered a store question in prose; ask once more and
+		// insist on a tool call so it does not make up prices
+		if *retryToolCalls && len(tools) > 0 && resp.Choices[0].Message.ToolCalls == nil && needsToolCall(input) {
+			fmt.Printf("[trace %s] No tool call for a store question, retrying\n", traceID)
+			req.Messages = append(req.Messages, openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: toolRetryInstruction,
+			})
+			req.ToolChoice = "required"
+
+			start = time.Now()
+			ctx, cancel := openAIContext()
+			retryResp, err := client.CreateChatCompletion(ctx, req)
+			cancel()
+			fmt.Printf("[trace %s] OpenAI API retry response time: %v\n", traceID, time.Since(start))
+			if err != nil {
+				fmt.Printf("%s; keeping the first answer\n", describeOpenAIError(err))
+			} else {
+				sessionCost.Record(retryResp.Usage)
+				resp = retryResp
+			}
+		}
 
 		// Process OpenAI response
 		message := resp.Choices[0].Message
@@ -347,12 +899,28 @@ func main() {
 			var lastResult string
 			var lastStructuredResult map[string]interface{}
 
-			for _, toolCall := range message.ToolCalls {
+			toolCalls := message.ToolCalls
+			if maxToolCalls > 0 && len(toolCalls) > maxToolCalls {
+				fmt.Printf("[trace %s] Model requested %d tool calls, truncating to %d\n", traceID, len(toolCalls), maxToolCalls)
+				fmt.Printf("這個問題需要的步驟太多，只執行了前 %d 個工具呼叫，結果可能不完整。\n", maxToolCalls)
+				toolCalls = toolCalls[:maxToolCalls]
+			}
+			if *verbose {
+				printRequestedCalls(os.Stdout, traceID, toolCalls)
+			}
+
+			for i, toolCall := range toolCalls {
+				if *verbose {
+					printCallHeader(os.Stdout, traceID, i+1, toolCall.Function.Name)
+				}
 				var arguments map[string]interface{}
 				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &arguments); err != nil {
 					fmt.Printf("Error parsing arguments: %v\n", err)
 					continue
 				}
+				if changes := normalizeArguments(inputSchemas[toolCall.Function.Name], arguments); len(changes) > 0 {
+					fmt.Printf("[trace %s] Normalized %s arguments: %s\n", traceID, toolCall.Function.Name, strings.Join(changes, ", "))
+				}
 
 				// If it's apply_discount and we have a previous structured result with total_price
 				if toolCall.Function.Name == "apply_discount" && lastStructuredResult != nil {
@@ -362,13 +930,47 @@ func main() {
 							fmt.Printf("自動使用前一步的總價: $%.2f\n", price)
 						}
 					}
+					// Pass the server's proof of the total along with it
+					if token, ok := lastStructuredResult["total_token"].(string); ok && token != "" {
+						arguments["total_token"] = token
+					}
 				}
 
-				// Call MCP server
-				response, err := server.CallTool(toolCall.Function.Name, arguments)
-				if err != nil {
-					fmt.Printf("Error calling tool: %v\n", err)
-					continue
+				// Show what an order would cost and let the user back out
+				// before it is placed
+				if !*assumeYes && needsConfirmation(toolCall.Function.Name, arguments) {
+					if !confirmOrder(server, lineReader, os.Stdout, traceID, arguments) {
+						fmt.Println("Order cancelled, nothing was placed.")
+						lastResult = orderNotConfirmed
+						lastStructuredResult = nil
+						continue
+					}
+				}
+
+				// Serve repeated deterministic calls from the cache when enabled
+				key, cacheable := "", false
+				if cache != nil {
+					key, cacheable = cacheKey(toolCall.Function.Name, arguments)
+				}
+				response, hit := "", false
+				if cacheable {
+					response, hit = cache.Get(key)
+				}
+
+				if hit {
+					fmt.Printf("[trace %s] %s served from cache\n", traceID, toolCall.Function.Name)
+				} else {
+					// Call MCP server
+					callStart := time.Now()
+					response, err = server.CallToolWithTrace(traceID, toolCall.Function.Name, arguments)
+					if err != nil {
+						fmt.Printf("[trace %s] Error calling tool: %v\n", traceID, err)
+						continue
+					}
+					fmt.Printf("[trace %s] %s response time: %v\n", traceID, toolCall.Function.Name, time.Since(callStart))
+					if cacheable {
+						cache.Put(key, response)
+					}
 				}
 
 				// Parse structured response
@@ -378,6 +980,10 @@ func main() {
 					continue
 				}
 
+				if *verbose {
+					printParsedResult(os.Stdout, structuredResult)
+				}
+
 				// Store for potential use in next tool call
 				lastStructuredResult = structuredResult
 
@@ -392,35 +998,35 @@ func main() {
 					fmt.Printf("結構化數據: %+v\n", structuredResult)
 				}
 			}
+			if *verbose {
+				printTurnEnd(os.Stdout, traceID)
+			}
 
-			// Use LLM to polish the final response
-			polishedResp, err := client.CreateChatCompletion(
-				context.Background(),
-				openai.ChatCompletionRequest{
-					Model: openai.GPT4TurboPreview,
-					Messages: []openai.ChatCompletionMessage{
-						{
-							Role: openai.ChatMessageRoleSystem,
-							Content: `You are a friendly store assistant. Please convert the system's response into a more friendly and natural conversation format.
-If the response is an error message, please tell the user about the problem in a more friendly way and provide suggestions.
-Please maintain a professional but friendly tone and respond in Traditional Chinese.
-If the response includes discount calculations, please clearly explain the original price and the discounted price.`,
-						},
-						{
-							Role:    openai.ChatMessageRoleUser,
-							Content: fmt.Sprintf("User question: %s\nSystem response: %s", input, lastResult),
-						},
-					},
-				},
-			)
-
-			if err != nil {
-				fmt.Printf("Error polishing response: %v\n", err)
-			} else {
-				fmt.Printf("\n%s\n", polishedResp.Choices[0].Message.Content)
+			// Use LLM to polish the final response, unless it is disabled or
+			// the same question already got the same result
+			answer := lastResult
+			if !*noPolish {
+				if cached, ok := polished.Get(input, lastResult); ok {
+					fmt.Printf("[trace %s] Reusing the polished reply for an identical result\n", traceID)
+					fmt.Printf("\n%s\n", cached)
+					answer = cached
+				} else {
+					reply, err := polishResponse(client, input, lastResult)
+					if err != nil {
+						fmt.Println(describeOpenAIError(err))
+					} else if reply != "" {
+						polished.Put(input, lastResult, reply)
+					}
+					if reply != "" {
+						answer = reply
+					}
+				}
 			}
+			memory.Add(input, answer)
 		} else {
 			fmt.Printf("\n%s\n", message.Content)
+			memory.Add(input, message.Content)
 		}
+		sessionCost.Report(os.Stdout, traceID, turnStart)
 	}
 }
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestListToolsFollowsCursor(t *testing.T) {
+	var cursors []interface{}
+	server := newFakeServer(t, func(request map[string]interface{}) []string {
+		params, _ := request["params"].(map[string]interface{})
+		cursors = append(cursors, params["cursor"])
+		tool := func(name string) map[string]interface{} {
+			return map[string]interface{}{"name": name, "inputSchema": map[string]interface{}{"type": "object"}}
+		}
+		if params["cursor"] == nil {
+			return []string{rpcResult(request["id"], map[string]interface{}{
+				"tools":      []interface{}{tool("get_price"), tool("calculate_total")},
+				"nextCursor": "page-2",
+			})}
+		}
+		return []string{rpcResult(request["id"], map[string]interface{}{
+			"tools": []interface{}{tool("place_order")},
+		})}
+	})
+
+	tools, err := server.ListTools()
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Function.Name)
+	}
+	if got := strings.Join(names, ","); got != "get_price,calculate_total,place_order" {
+		t.Errorf("tools are %s, want both pages in order", got)
+	}
+	if len(cursors) != 2 || cursors[0] != nil || cursors[1] != "page-2" {
+		t.Errorf("requested cursors %v, want no cursor then page-2", cursors)
+	}
+}
+
+func TestListToolsStopsOnRepeatedCursor(t *testing.T) {
+	requests := 0
+	server := newFakeServer(t, func(request map[string]interface{}) []string {
+		requests++
+		return []string{rpcResult(request["id"], map[string]interface{}{
+			"tools":      []interface{}{map[string]interface{}{"name": "get_price"}},
+			"nextCursor": "stuck",
+		})}
+	})
+
+	if _, err := server.ListTools(); err == nil || !strings.Contains(err.Error(), "same cursor") {
+		t.Fatalf("ListTools returned %v, want a repeated cursor error", err)
+	}
+	if requests != 2 {
+		t.Errorf("sent %d tools/list requests, want 2", requests)
+	}
+}
+
+// echoServer answers every tool call with its own arguments as text. Each
+// answer is delayed a little so that unsynchronized callers would overlap.
+func echoServer(t *testing.T) *MCPServer {
+	return newFakeServer(t, func(request map[string]interface{}) []string {
+		time.Sleep(100 * time.Microsecond)
+		params, _ := request["params"].(map[string]interface{})
+		arguments, _ := json.Marshal(params["arguments"])
+		return []string{rpcResult(request["id"], map[string]interface{}{
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": string(arguments)}},
+		})}
+	})
+}
+
+func TestCallToolConcurrentCallers(t *testing.T) {
+	server := echoServer(t)
+
+	const callers = 50
+	var wg sync.WaitGroup
+	got := make([]string, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := server.CallTool("echo", map[string]interface{}{"caller": i})
+			got[i], errs[i] = extractContentFromResponse(response), err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range got {
+		if errs[i] != nil {
+			t.Errorf("caller %d: %v", i, errs[i])
+			continue
+		}
+		if want := fmt.Sprintf(`{"caller":%d}`, i); got[i] != want {
+			t.Errorf("caller %d got %s, want its own response %s", i, got[i], want)
+		}
+	}
+}
+
+// toolResponse encodes a tools/call response holding the given content blocks
+func toolResponse(blocks ...map[string]interface{}) string {
+	content := make([]interface{}, len(blocks))
+	for i, block := range blocks {
+		content[i] = block
+	}
+	return rpcResult(3, map[string]interface{}{"content": content})
+}
+
+func textBlock(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "text", "text": text}
+}
+
+func TestExtractContentFromResponse(t *testing.T) {
+	errorResponse := `{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"tool not found"}}`
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"one block", toolResponse(textBlock("The price is $1000")), "The price is $1000"},
+		{"summary and detail", toolResponse(textBlock("Summary"), textBlock("Detail")), "Summary\nDetail"},
+		{"non-text blocks skipped", toolResponse(textBlock("Summary"), map[string]interface{}{"type": "image", "data": "AAAA"}, textBlock("Detail")), "Summary\nDetail"},
+		{"no content", errorResponse, errorResponse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractContentFromResponse(tt.response); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStructuredResponseMultiBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     map[string]interface{}
+	}{
+		{
+			"summary before the JSON block",
+			toolResponse(textBlock("Total computed"), textBlock(`{"success":true,"total_price":1500}`)),
+			map[string]interface{}{"success": true, "total_price": 1500.0},
+		},
+		{
+			"embedded resource wins over text",
+			toolResponse(textBlock(`{"success":false}`), map[string]interface{}{
+				"type":     "resource",
+				"resource": map[string]interface{}{"uri": "store://result", "mimeType": "application/json", "text": `{"success":true,"price":500}`},
+			}),
+			map[string]interface{}{"success": true, "price": 500.0},
+		},
+		{
+			"plain text blocks become the message",
+			toolResponse(textBlock("First line"), textBlock("Second line")),
+			map[string]interface{}{"success": true, "message": "First line\nSecond line"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStructuredResponse(tt.response)
+			if err != nil {
+				t.Fatalf("parseStructuredResponse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitializeSendsInitializedNotification(t *testing.T) {
+	var methods []string
+	var notification map[string]interface{}
+	server := newFakeServer(t, func(request map[string]interface{}) []string {
+		method, _ := request["method"].(string)
+		methods = append(methods, method)
+		switch method {
+		case "initialize":
+			return []string{rpcResult(request["id"], map[string]interface{}{
+				"serverInfo":   map[string]interface{}{"name": "fake", "version": "1.0.0"},
+				"capabilities": map[string]interface{}{"tools": map[string]interface{}{}},
+			})}
+		case "notifications/initialized":
+			notification = request
+			return nil
+		}
+		return []string{rpcResult(request["id"], map[string]interface{}{
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "ok"}},
+		})}
+	})
+
+	if err := server.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	// A call after Initialize makes sure the fake has seen everything before it
+	if _, err := server.CallTool("get_price", map[string]interface{}{}); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+
+	want := []string{"initialize", "notifications/initialized", "tools/call"}
+	if !reflect.DeepEqual(methods, want) {
+		t.Fatalf("server saw %v, want %v", methods, want)
+	}
+	if _, hasID := notification["id"]; hasID {
+		t.Errorf("notification %v has an id, notifications must not", notification)
+	}
+	if !server.HasToolsCapability() {
+		t.Error("tools capability not recorded from the initialize response")
+	}
+}
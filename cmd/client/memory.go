@@ -0,0 +1,59 @@
+package main
+
+import (
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// conversationMemory keeps the most recent user/assistant exchanges so that
+// follow-up questions such as "那加上三台呢?" keep their context
+type conversationMemory struct {
+	messages  []openai.ChatCompletionMessage
+	maxTurns  int
+	maxTokens int
+}
+
+func newConversationMemory(maxTurns, maxTokens int) *conversationMemory {
+	return &conversationMemory{maxTurns: maxTurns, maxTokens: maxTokens}
+}
+
+// estimateTokens gives a rough, deliberately generous token count. Chinese
+// text is close to one token per character, so runes are counted directly.
+func estimateTokens(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// Add records one turn and drops the oldest turns that no longer fit
+func (m *conversationMemory) Add(question, answer string) {
+	if m.maxTurns <= 0 {
+		return
+	}
+	m.messages = append(m.messages,
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: question},
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: answer},
+	)
+	m.trim()
+}
+
+// trim removes whole turns, oldest first, until both limits are respected
+func (m *conversationMemory) trim() {
+	for len(m.messages) > 0 && (len(m.messages)/2 > m.maxTurns || m.tokens() > m.maxTokens) {
+		m.messages = m.messages[2:]
+	}
+}
+
+func (m *conversationMemory) tokens() int {
+	total := 0
+	for _, msg := range m.messages {
+		total += estimateTokens(msg.Content)
+	}
+	return total
+}
+
+// Messages returns the remembered messages, oldest first
+func (m *conversationMemory) Messages() []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// toolInputSchemas maps each tool name to the input schema the server
+// listed for it
+func toolInputSchemas(tools []openai.Tool) map[string]map[string]interface{} {
+	schemas := make(map[string]map[string]interface{}, len(tools))
+	for _, tool := range tools {
+		if tool.Function == nil {
+			continue
+		}
+		if schema, ok := tool.Function.Parameters.(map[string]interface{}); ok {
+			schemas[tool.Function.Name] = schema
+		}
+	}
+	return schemas
+}
+
+// normalizeArguments coerces the arguments the model sent to the types in
+// the tool's input schema, such as a product_id of 1 to "1" or a quantity of
+// "3" to 3, and returns a description of each change. Values that cannot be
+// converted cleanly are left for the server to reject.
+func normalizeArguments(schema map[string]interface{}, arguments map[string]interface{}) []string {
+	var changes []string
+	normalizeObject(schema, arguments, "", &changes)
+	return changes
+}
+
+// normalizeObject normalizes the properties of an object argument in place
+func normalizeObject(schema map[string]interface{}, object map[string]interface{}, path string, changes *[]string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	// Visit properties in order so changes are reported the same way each time
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propSchema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field := name
+		if path != "" {
+			field = path + "." + name
+		}
+		object[name] = normalizeValue(propSchema, object[name], field, changes)
+	}
+}
+
+// normalizeValue returns value converted to a type the schema accepts,
+// recording the change, or value itself when it already fits or cannot be
+// converted
+func normalizeValue(schema map[string]interface{}, value interface{}, field string, changes *[]string) interface{} {
+	types := schemaTypes(schema)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if types["object"] {
+			normalizeObject(schema, v, field, changes)
+		}
+		return v
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok && types["array"] {
+			for i := range v {
+				v[i] = normalizeValue(items, v[i], fmt.Sprintf("%s[%d]", field, i), changes)
+			}
+		}
+		return v
+	case float64:
+		if types["number"] || (types["integer"] && v == float64(int64(v))) {
+			return v
+		}
+		if types["string"] {
+			s := strconv.FormatFloat(v, 'f', -1, 64)
+			*changes = append(*changes, fmt.Sprintf("%s: %v -> %q", field, v, s))
+			return s
+		}
+	case string:
+		// Numbers sent as strings are converted even when the schema also
+		// accepts strings, so "3" reaches the server as 3
+		s := strings.TrimSpace(v)
+		if types["integer"] {
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				*changes = append(*changes, fmt.Sprintf("%s: %q -> %d", field, v, n))
+				return float64(n)
+			}
+		}
+		if types["number"] {
+			if n, err := strconv.ParseFloat(s, 64); err == nil {
+				*changes = append(*changes, fmt.Sprintf("%s: %q -> %v", field, v, n))
+				return n
+			}
+		}
+		if types["boolean"] && !types["string"] {
+			if b, err := strconv.ParseBool(s); err == nil {
+				*changes = append(*changes, fmt.Sprintf("%s: %q -> %v", field, v, b))
+				return b
+			}
+		}
+	}
+	return value
+}
+
+// schemaTypes returns the set of JSON types a schema allows, whether its
+// type is a single name or a list of names
+func schemaTypes(schema map[string]interface{}) map[string]bool {
+	types := make(map[string]bool)
+	switch t := schema["type"].(type) {
+	case string:
+		types[t] = true
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types[s] = true
+			}
+		}
+	}
+	return types
+}
@@ -0,0 +1,39 @@
+package main
+
+// maxPolishCacheEntries bounds how many polished replies are remembered
+const maxPolishCacheEntries = 100
+
+// polishCache remembers the polished reply for a question and tool result
+// pair, so asking the same question again with the same result skips the
+// second model call. The oldest entry is evicted when the cache is full.
+type polishCache struct {
+	entries map[string]string
+	order   []string
+}
+
+func newPolishCache() *polishCache {
+	return &polishCache{entries: make(map[string]string)}
+}
+
+func polishCacheKey(input, result string) string {
+	return input + "\x00" + result
+}
+
+// Get returns the polished reply for the question and result
+func (c *polishCache) Get(input, result string) (string, bool) {
+	answer, ok := c.entries[polishCacheKey(input, result)]
+	return answer, ok
+}
+
+// Put stores the polished reply for the question and result
+func (c *polishCache) Put(input, result, answer string) {
+	key := polishCacheKey(input, result)
+	if _, exists := c.entries[key]; !exists {
+		if len(c.order) >= maxPolishCacheEntries {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = answer
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ServerPool spreads tool calls over several server subprocesses. A stdio
+// server answers one request at a time, so a pool of N servers can run N
+// calls in parallel, e.g. to benchmark the tool handlers.
+type ServerPool struct {
+	spawn   func() (*MCPServer, error)
+	members []*MCPServer
+	// free holds the indexes of idle members; whoever takes an index owns
+	// that member until it puts the index back
+	free chan int
+}
+
+// NewServerPool starts size servers with spawn
+func NewServerPool(size int, spawn func() (*MCPServer, error)) (*ServerPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("pool size must be at least 1, got %d", size)
+	}
+	p := &ServerPool{
+		spawn:   spawn,
+		members: make([]*MCPServer, size),
+		free:    make(chan int, size),
+	}
+	for i := range p.members {
+		member, err := spawn()
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("failed to start pool member %d: %w", i, err)
+		}
+		p.members[i] = member
+		p.free <- i
+	}
+	return p, nil
+}
+
+// Size returns the number of servers in the pool
+func (p *ServerPool) Size() int {
+	return len(p.members)
+}
+
+// Call runs a tool call on the first idle server, waiting for one if all are
+// busy. A server that has died is replaced with a new one; the call that
+// found it dead still fails, since it may have been partly processed.
+func (p *ServerPool) Call(traceID, name string, arguments map[string]interface{}) (string, error) {
+	i := <-p.free
+	defer func() { p.free <- i }()
+
+	response, err := p.members[i].CallToolWithTrace(traceID, name, arguments)
+	if err == nil || !connectionLost(err) {
+		return response, err
+	}
+
+	p.members[i].Close()
+	replacement, spawnErr := p.spawn()
+	if spawnErr != nil {
+		return "", fmt.Errorf("%w (pool member %d died and could not be restarted: %v)", err, i, spawnErr)
+	}
+	p.members[i] = replacement
+	return "", fmt.Errorf("%w (pool member %d died and was restarted)", err, i)
+}
+
+// Close stops every server in the pool
+func (p *ServerPool) Close() {
+	for _, member := range p.members {
+		if member != nil {
+			member.Close()
+		}
+	}
+}
+
+// connectionLost reports whether err means the server process is gone rather
+// than that a single request failed
+func connectionLost(err error) bool {
+	var malformed *malformedLineError
+	switch {
+	case errors.Is(err, errServerClosed), errors.Is(err, syscall.EPIPE), errors.Is(err, os.ErrClosed):
+		return true
+	case errors.As(err, &malformed):
+		return malformed.Closed
+	}
+	return false
+}
+
+// runBenchCommand handles "/bench <calls> <tool> [json arguments]" by running
+// the tool call the given number of times in parallel across the pool and
+// reporting throughput and latency
+func runBenchCommand(pool *ServerPool, w io.Writer, line string) {
+	if pool == nil {
+		fmt.Fprintln(w, "Start the client with -pool N to use /bench")
+		return
+	}
+	usage := `Usage: /bench <calls> <tool> [json arguments], e.g. /bench 100 get_price {"product_id":"1"}`
+
+	rest := strings.TrimSpace(strings.TrimPrefix(line, "/bench"))
+	countText, rest, _ := strings.Cut(rest, " ")
+	calls, err := strconv.Atoi(countText)
+	if err != nil || calls < 1 {
+		fmt.Fprintln(w, usage)
+		return
+	}
+	name, arguments, err := parseToolInvocation(strings.TrimSpace(rest))
+	if err != nil {
+		fmt.Fprintln(w, err)
+		fmt.Fprintln(w, usage)
+		return
+	}
+
+	latencies := make([]time.Duration, calls)
+	var failures sync.Map
+	var wg sync.WaitGroup
+	start := time.Now()
+	for n := 0; n < calls; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			callStart := time.Now()
+			response, err := pool.Call(newTraceID(), name, arguments)
+			latencies[n] = time.Since(callStart)
+			if err == nil {
+				var structured map[string]interface{}
+				structured, err = parseStructuredResponse(response)
+				if success, ok := structured["success"].(bool); err == nil && ok && !success {
+					err = fmt.Errorf("%v", structured["error"])
+				}
+			}
+			if err != nil {
+				failures.Store(n, err)
+			}
+		}(n)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	errorCount := 0
+	var firstErr error
+	failures.Range(func(_, v interface{}) bool {
+		errorCount++
+		if firstErr == nil {
+			firstErr = v.(error)
+		}
+		return true
+	})
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+
+	fmt.Fprintf(w, "%d calls to %s on %d servers in %v (%.1f calls/s), %d errors\n",
+		calls, name, pool.Size(), elapsed.Round(time.Millisecond), float64(calls)/elapsed.Seconds(), errorCount)
+	fmt.Fprintf(w, "latency p50 %v, p95 %v, max %v\n", percentile(0.5), percentile(0.95), latencies[len(latencies)-1])
+	if firstErr != nil {
+		fmt.Fprintf(w, "first error: %v\n", firstErr)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestServerPoolConcurrentCalls(t *testing.T) {
+	pool, err := NewServerPool(4, func() (*MCPServer, error) { return echoServer(t), nil })
+	if err != nil {
+		t.Fatalf("NewServerPool: %v", err)
+	}
+	defer pool.Close()
+
+	const calls = 100
+	var wg sync.WaitGroup
+	got := make([]string, calls)
+	errs := make([]error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := pool.Call("", "echo", map[string]interface{}{"call": i})
+			got[i], errs[i] = extractContentFromResponse(response), err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range got {
+		if errs[i] != nil {
+			t.Errorf("call %d: %v", i, errs[i])
+			continue
+		}
+		if want := fmt.Sprintf(`{"call":%d}`, i); got[i] != want {
+			t.Errorf("call %d got %s, want its own response %s", i, got[i], want)
+		}
+	}
+}
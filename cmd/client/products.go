@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// productsPageSize is the page size the /products command asks for
+const productsPageSize = 20
+
+// runProductsCommand handles "/products" by paging through list_products
+// until the server reports the last page, printing every product
+func runProductsCommand(server *MCPServer, w io.Writer) {
+	offset := 0
+	for {
+		response, err := server.CallTool("list_products", map[string]interface{}{
+			"limit":  productsPageSize,
+			"offset": offset,
+		})
+		if err != nil {
+			fmt.Fprintf(w, "Error calling list_products: %v\n", err)
+			return
+		}
+		page, err := parseStructuredResponse(response)
+		if err != nil {
+			fmt.Fprintf(w, "Error parsing list_products response: %v\n", err)
+			return
+		}
+		if success, _ := page["success"].(bool); !success {
+			fmt.Fprintf(w, "list_products failed: %v\n", page["message"])
+			return
+		}
+
+		products, _ := page["products"].([]interface{})
+		for _, p := range products {
+			product, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			line := fmt.Sprintf("  %-6v %-20v %10.2f  %v", product["product_id"], product["product_name"], product["price"], product["category"])
+			if available, ok := product["available"].(bool); ok && !available {
+				line += " (preorder)"
+			}
+			fmt.Fprintln(w, line)
+		}
+
+		// next_offset is null on the last page
+		next, ok := page["next_offset"].(float64)
+		if !ok || int(next) <= offset {
+			fmt.Fprintf(w, "%v products\n", page["total"])
+			return
+		}
+		offset = int(next)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// defaultReadTimeout bounds how long the client waits for a response line
+const defaultReadTimeout = 30 * time.Second
+
+var (
+	// errServerClosed means the server closed its output between responses
+	errServerClosed = errors.New("server closed connection")
+	// errReadTimeout means no complete response line arrived in time
+	errReadTimeout = errors.New("timeout waiting for server response")
+)
+
+// malformedLineError reports a response line that cannot be used, either
+// because it is not JSON or because the server stopped mid-line
+type malformedLineError struct {
+	Line   string
+	Reason string
+	// Closed is set when the server closed its output mid-line
+	Closed bool
+}
+
+func (e *malformedLineError) Error() string {
+	line := e.Line
+	if len(line) > 200 {
+		line = line[:200] + "..."
+	}
+	return fmt.Sprintf("malformed line from server (%s): %q", e.Reason, line)
+}
+
+// deadlineReader is implemented by pipes that support read deadlines, such
+// as the *os.File returned by exec.Cmd.StdoutPipe on most platforms
+type deadlineReader interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// SetReadTimeout sets how long each response may take; 0 waits forever
+func (s *MCPServer) SetReadTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readTimeout = timeout
+}
+
+// readResponse returns the next response line, first discarding messages the
+// server sends on its own, such as notifications, and responses to earlier
+// requests that timed out. Callers must hold mu.
+func (s *MCPServer) readResponse() (string, error) {
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			if errors.Is(err, errReadTimeout) {
+				// The server may still answer; skip that answer next time
+				s.abandoned++
+			}
+			return "", err
+		}
+		if !isResponse(line) {
+			continue
+		}
+		if s.abandoned > 0 {
+			s.abandoned--
+			continue
+		}
+		return line, nil
+	}
+}
+
+// readLine reads one newline-terminated JSON line. Data read before a
+// timeout is kept so the line can be completed by the next read. Callers
+// must hold mu.
+func (s *MCPServer) readLine() (string, error) {
+	if pipe, ok := s.stdout.(deadlineReader); ok {
+		deadline := time.Time{}
+		if s.readTimeout > 0 {
+			deadline = time.Now().Add(s.readTimeout)
+		}
+		// Pipes without deadline support fall back to blocking reads
+		_ = pipe.SetReadDeadline(deadline)
+	}
+
+	data, err := s.reader.ReadBytes('\n')
+	s.partial = append(s.partial, data...)
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			if len(s.partial) > 0 {
+				return "", fmt.Errorf("%w after %v (%d bytes of a partial line received)", errReadTimeout, s.readTimeout, len(s.partial))
+			}
+			return "", fmt.Errorf("%w after %v", errReadTimeout, s.readTimeout)
+		case errors.Is(err, io.EOF):
+			if len(s.partial) > 0 {
+				partial := string(s.partial)
+				s.partial = nil
+				return "", &malformedLineError{Line: partial, Reason: "connection closed before the end of the line", Closed: true}
+			}
+			return "", errServerClosed
+		default:
+			return "", fmt.Errorf("failed to read from server: %w", err)
+		}
+	}
+
+	line := bytes.TrimRight(s.partial, "\r\n")
+	s.partial = nil
+	if !json.Valid(line) {
+		return "", &malformedLineError{Line: string(line), Reason: "not valid JSON"}
+	}
+	return string(line), nil
+}
+
+// isResponse reports whether a JSON-RPC line answers a request. Notifications
+// and requests from the server carry a method; responses never do.
+func isResponse(line string) bool {
+	var message struct {
+		Method string `json:"method"`
+	}
+	return json.Unmarshal([]byte(line), &message) != nil || message.Method == ""
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+// textResult encodes a tools/call response with a single text block
+func textResult(id interface{}, text string) string {
+	return rpcResult(id, map[string]interface{}{
+		"content": []interface{}{map[string]interface{}{"type": "text", "text": text}},
+	})
+}
+
+func TestReadResponseSkipsServerMessages(t *testing.T) {
+	server := newFakeServer(t, func(request map[string]interface{}) []string {
+		return []string{
+			`{"jsonrpc":"2.0","method":"notifications/message","params":{"level":"info","data":"working"}}`,
+			`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":1,"progress":50}}`,
+			textResult(request["id"], "the answer"),
+		}
+	})
+
+	response, err := server.CallTool("get_price", map[string]interface{}{"product_id": "1"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if got := extractContentFromResponse(response); got != "the answer" {
+		t.Errorf("got %q, want the response after the notifications", got)
+	}
+}
+
+func TestReadResponseSkipsAbandonedResponses(t *testing.T) {
+	server := newFakeServer(t, func(request map[string]interface{}) []string {
+		return []string{
+			textResult(request["id"], "late answer to the call that timed out"),
+			`{"jsonrpc":"2.0","method":"notifications/message","params":{"level":"info","data":"working"}}`,
+			textResult(request["id"], "the answer"),
+		}
+	})
+	// As if the previous call had timed out before its response arrived
+	server.abandoned = 1
+
+	response, err := server.CallTool("get_price", map[string]interface{}{"product_id": "1"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if got := extractContentFromResponse(response); got != "the answer" {
+		t.Errorf("got %q, want the response after the abandoned one", got)
+	}
+	if server.abandoned != 0 {
+		t.Errorf("%d responses still marked abandoned, want 0", server.abandoned)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "strings"
+
+// toolRetryInstruction is added to the conversation when the model answered
+// a question that needs store data without calling a tool
+const toolRetryInstruction = `The user's question is about products, prices, totals or discounts. ` +
+	`Do not answer from memory: call the appropriate tool and base the answer on its result.`
+
+// toolKeywords are words that indicate a question can only be answered with
+// data from the store's tools
+var toolKeywords = []string{
+	"多少錢", "多少钱", "價格", "价格", "價錢", "价钱", "總價", "总价", "總共", "总共",
+	"折", "運費", "运费", "庫存", "库存", "下單", "下单", "訂單", "订单", "點數", "积分",
+	"筆電", "笔电", "手機", "手机", "平板",
+	"price", "cost", "total", "discount", "shipping", "stock", "order", "$",
+}
+
+// needsToolCall reports whether the input looks like a question that should
+// have been answered with a tool call
+func needsToolCall(input string) bool {
+	lower := strings.ToLower(input)
+	for _, keyword := range toolKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
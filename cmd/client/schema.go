@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// supportedSchemaVersion is the newest structured result format this client
+// understands
+const supportedSchemaVersion = 1
+
+// warnedSchemaVersions remembers the unknown versions already reported
+var warnedSchemaVersions sync.Map
+
+// checkSchemaVersion warns once per version when a structured result uses a
+// format newer than this client knows. The result is still used, so fields
+// that kept their meaning keep working. Results from servers that predate
+// schema_version carry no version and are accepted silently.
+func checkSchemaVersion(structured map[string]interface{}) {
+	raw, ok := structured["schema_version"]
+	if !ok {
+		return
+	}
+	version, ok := raw.(float64)
+	if ok && version >= 1 && version <= supportedSchemaVersion && version == float64(int(version)) {
+		return
+	}
+	if _, warned := warnedSchemaVersions.LoadOrStore(fmt.Sprint(raw), true); warned {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: server result uses schema_version %v, this client supports up to %d; some fields may be misread\n", raw, supportedSchemaVersion)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// printTools writes a terminal-friendly reference of the tools fetched from
+// the server: each tool's name, the first line of its description and a
+// summary of its parameters
+func printTools(w io.Writer, tools []openai.Tool) {
+	if len(tools) == 0 {
+		fmt.Fprintln(w, "The server does not offer any tools")
+		return
+	}
+
+	fmt.Fprintf(w, "\n%d tools available:\n", len(tools))
+	for _, tool := range tools {
+		if tool.Function == nil {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\n", tool.Function.Name)
+		if description := firstLine(tool.Function.Description); description != "" {
+			fmt.Fprintf(w, "  %s\n", description)
+		}
+
+		schema, _ := tool.Function.Parameters.(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		if len(properties) == 0 {
+			fmt.Fprintln(w, "  (no parameters)")
+			continue
+		}
+
+		required := make(map[string]bool)
+		if names, ok := schema["required"].([]interface{}); ok {
+			for _, name := range names {
+				if s, ok := name.(string); ok {
+					required[s] = true
+				}
+			}
+		}
+
+		// Required parameters first, then the rest, each in name order
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if required[names[i]] != required[names[j]] {
+				return required[names[i]]
+			}
+			return names[i] < names[j]
+		})
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, name := range names {
+			prop, _ := properties[name].(map[string]interface{})
+			flag := "optional"
+			if required[name] {
+				flag = "required"
+			}
+			fmt.Fprintf(tw, "  - %s\t%s\t%s\t%s\n", name, describeType(prop), flag, firstLine(stringField(prop, "description")))
+		}
+		tw.Flush()
+	}
+}
+
+// describeType summarizes a property schema, e.g. "string (sequential|best)"
+// or "array of {product_id, quantity}"
+func describeType(prop map[string]interface{}) string {
+	var desc string
+	switch t := prop["type"].(type) {
+	case string:
+		desc = t
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		desc = strings.Join(types, "|")
+	default:
+		desc = "any"
+	}
+
+	if desc == "array" {
+		if items, ok := prop["items"].(map[string]interface{}); ok {
+			if fields, ok := items["properties"].(map[string]interface{}); ok && len(fields) > 0 {
+				names := make([]string, 0, len(fields))
+				for name := range fields {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				return fmt.Sprintf("array of {%s}", strings.Join(names, ", "))
+			}
+			return "array of " + describeType(items)
+		}
+	}
+
+	if enum, ok := prop["enum"].([]interface{}); ok && len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		for _, v := range enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		desc += " (" + strings.Join(values, "|") + ")"
+	}
+	return desc
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// firstLine returns the first non-empty line of a possibly multi-line text
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// parseToolInvocation splits "<tool> [json arguments]" into the tool name
+// and its arguments
+func parseToolInvocation(text string) (string, map[string]interface{}, error) {
+	name, rawArgs, _ := strings.Cut(text, " ")
+	if name == "" {
+		return "", nil, fmt.Errorf("missing tool name")
+	}
+	arguments := map[string]interface{}{}
+	if rawArgs = strings.TrimSpace(rawArgs); rawArgs != "" {
+		if err := json.Unmarshal([]byte(rawArgs), &arguments); err != nil {
+			return "", nil, fmt.Errorf("Invalid JSON arguments for %s: %v", name, err)
+		}
+	}
+	return name, arguments, nil
+}
+
+// runCallCommand handles "/call <tool> [json arguments]" by calling the tool
+// directly, bypassing the model, and printing the raw and structured responses
+func runCallCommand(server *MCPServer, w io.Writer, line string) {
+	rest := strings.TrimSpace(strings.TrimPrefix(line, "/call"))
+	if rest == "" {
+		fmt.Fprintln(w, `Usage: /call <tool> [json arguments], e.g. /call get_price {"product_id":"1"}`)
+		return
+	}
+
+	name, arguments, err := parseToolInvocation(rest)
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return
+	}
+
+	traceID := newTraceID()
+	start := time.Now()
+	response, err := server.CallToolWithTrace(traceID, name, arguments)
+	if err != nil {
+		fmt.Fprintf(w, "[trace %s] Error calling tool: %v\n", traceID, err)
+		return
+	}
+	fmt.Fprintf(w, "[trace %s] %s response time: %v\n", traceID, name, time.Since(start))
+
+	fmt.Fprintf(w, "\nRaw response:\n%s\n", response)
+
+	structured, err := parseStructuredResponse(response)
+	if err != nil {
+		fmt.Fprintf(w, "Error parsing structured response: %v\n", err)
+		return
+	}
+	pretty, err := json.MarshalIndent(structured, "", "  ")
+	if err != nil {
+		fmt.Fprintf(w, "Error formatting structured response: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "\nStructured response:\n%s\n", pretty)
+}
+
+// runHelpCommand prints the server's own help when it offers a help tool,
+// falling back to a help built from the client commands and the tool schemas
+// so "help" works whichever server build is connected
+func runHelpCommand(server *MCPServer, w io.Writer, tools []openai.Tool) {
+	if hasTool(tools, "help") {
+		response, err := server.CallTool("help", map[string]interface{}{})
+		if err == nil {
+			// A response without text content, such as a JSON-RPC error,
+			// comes back unchanged
+			if text := extractContentFromResponse(response); text != response {
+				fmt.Fprintln(w, text)
+				return
+			}
+			err = fmt.Errorf("no help text in response")
+		}
+		fmt.Fprintf(w, "The server's help tool failed (%v), showing the built-in help\n", err)
+	}
+	printBuiltinHelp(w, tools)
+}
+
+// printBuiltinHelp lists the client commands followed by the server's tools
+func printBuiltinHelp(w io.Writer, tools []openai.Tool) {
+	fmt.Fprintln(w, "Commands:")
+	printCommands(w, tools)
+	printTools(w, tools)
+}
+
+// printDirectModeBanner explains the limited mode the client runs in when no
+// OpenAI API key is configured
+func printDirectModeBanner(w io.Writer) {
+	fmt.Fprintln(w, "\n================ Direct tool mode ================")
+	fmt.Fprintln(w, "OPENAI_API_KEY is not set, so questions in plain language")
+	fmt.Fprintln(w, "are disabled. The server connection is still available:")
+	fmt.Fprintln(w, "  tools                          list tools and parameters")
+	fmt.Fprintln(w, `  <tool> {"arg": ...}            call a tool, e.g. get_price {"product_id":"1"}`)
+	fmt.Fprintln(w, `  /call <tool> {"arg": ...}      same as above`)
+	fmt.Fprintln(w, "Set OPENAI_API_KEY and restart to ask questions.")
+	fmt.Fprintln(w, "==================================================")
+}
+
+// firstWord returns the text before the first space
+func firstWord(s string) string {
+	word, _, _ := strings.Cut(s, " ")
+	return word
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// SetTraceOutput makes CallToolWithTrace copy every raw JSON-RPC request and
+// response line to w; nil turns the copy off
+func (s *MCPServer) SetTraceOutput(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.traceOut = w
+}
+
+// traceLine writes one raw protocol line to the trace output, if any.
+// Callers must hold mu.
+func (s *MCPServer) traceLine(direction, line string) {
+	if s.traceOut != nil {
+		fmt.Fprintf(s.traceOut, "%s %s\n", direction, line)
+	}
+}
+
+// printRequestedCalls writes the tool calls the model asked for in a turn
+func printRequestedCalls(w io.Writer, traceID string, toolCalls []openai.ToolCall) {
+	fmt.Fprintf(w, "======== trace %s: %d tool call(s) requested ========\n", traceID, len(toolCalls))
+	for i, toolCall := range toolCalls {
+		fmt.Fprintf(w, "  %d. %s %s\n", i+1, toolCall.Function.Name, toolCall.Function.Arguments)
+	}
+}
+
+// printCallHeader opens the trace block of one tool call
+func printCallHeader(w io.Writer, traceID string, n int, name string) {
+	fmt.Fprintf(w, "-------- trace %s: call %d %s --------\n", traceID, n, name)
+}
+
+// printParsedResult writes the structured result parsed from a response
+func printParsedResult(w io.Writer, structured map[string]interface{}) {
+	pretty, err := json.MarshalIndent(structured, "", "  ")
+	if err != nil {
+		fmt.Fprintf(w, "parsed: %+v\n", structured)
+		return
+	}
+	fmt.Fprintf(w, "parsed:\n%s\n", pretty)
+}
+
+// printTurnEnd closes the trace of a turn
+func printTurnEnd(w io.Writer, traceID string) {
+	fmt.Fprintf(w, "======== end of trace %s ========\n", traceID)
+}
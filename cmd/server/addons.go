@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// giftWrapFeePerItem is charged per wrapped item when items_count is given
+	giftWrapFeePerItem = 3.0
+	// giftWrapFeePerOrder is charged once when the whole order is wrapped together
+	giftWrapFeePerOrder = 10.0
+)
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "total_price": {"type": "number"},
+	    "items_count": {"type": "number"}
+	  },
+	  "required": ["total_price"]
+	}
+*/
+func addGiftWrapHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	totalPrice, ok := args["total_price"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing total_price")
+	}
+	if totalPrice < 0 {
+		return jsonError(map[string]interface{}{
+			"success":     false,
+			"error":       "total_price cannot be negative",
+			"total_price": totalPrice,
+		}), nil
+	}
+
+	// Wrap each item separately when a count is given, otherwise wrap the
+	// order as a single package
+	feeType := "per_order"
+	feePerUnit := giftWrapFeePerOrder
+	fee := giftWrapFeePerOrder
+	itemsCount := 0
+	if count, ok := args["items_count"].(float64); ok {
+		if count != float64(int(count)) || count <= 0 {
+			return jsonError(map[string]interface{}{
+				"success":     false,
+				"error":       "items_count must be a positive integer",
+				"items_count": count,
+			}), nil
+		}
+		itemsCount = int(count)
+		feeType = "per_item"
+		feePerUnit = giftWrapFeePerItem
+		fee = roundMoney(giftWrapFeePerItem * count)
+	}
+
+	basePrice := roundMoney(totalPrice)
+	newTotal := roundMoney(basePrice + fee)
+
+	result := map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"base_price":    basePrice,
+		"gift_wrap":     fee,
+		"fee_type":      feeType,
+		"total_price":   newTotal,
+		"fee_per_unit":  feePerUnit,
+		"message":       localize(ctx, "gift_wrap", money(basePrice), money(fee), money(newTotal)),
+	}
+	if feeType == "per_item" {
+		result["items_count"] = itemsCount
+	}
+	return jsonResult(result), nil
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// adminEnabled controls whether the catalog mutation tools are registered
+var adminEnabled = false
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "product_id": {"type": "string"},
+	    "new_price": {"type": "number"}
+	  },
+	  "required": ["product_id", "new_price"]
+	}
+*/
+func updatePriceHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	productID, ok := args["product_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("product_id is not a string")
+	}
+
+	newPrice, ok := args["new_price"].(float64)
+	if !ok || math.IsNaN(newPrice) || math.IsInf(newPrice, 0) {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "new_price must be a number",
+			"product_id": productID,
+			"new_price":  args["new_price"],
+		}), nil
+	}
+	if newPrice < 0 {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "new_price cannot be negative",
+			"product_id": productID,
+			"new_price":  newPrice,
+		}), nil
+	}
+	newPrice = roundMoney(newPrice)
+
+	product, ok := catalog.Get(productID)
+	if !ok {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "Product not found",
+			"product_id": productID,
+		}), nil
+	}
+
+	oldPrice, err := catalog.UpdatePrice(productID, newPrice)
+	if err != nil {
+		// The product was removed between the lookup and the update
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "Product not found",
+			"product_id": productID,
+		}), nil
+	}
+
+	auditLog.record(ctx, "update_price", productID, oldPrice, newPrice)
+
+	return jsonResult(map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"product_id":    productID,
+		"product_name":  product.Name,
+		"old_price":     oldPrice,
+		"new_price":     newPrice,
+		"message":       localize(ctx, "price_updated", product.Name, money(oldPrice), money(newPrice)),
+	}), nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "id": {"type": "string"},
+	    "name": {"type": "string"},
+	    "price": {"type": "number"},
+	    "category": {"type": "string"},
+	    "stock": {"type": "number"},
+	    "max_per_order": {"type": "number"},
+	    "attributes": {"type": "object"},
+	    "image_url": {"type": "string"}
+	  },
+	  "required": ["id", "name", "price"]
+	}
+*/
+func addProductHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	id, _ := args["id"].(string)
+	name, _ := args["name"].(string)
+	if id == "" || name == "" {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "id and name are required",
+		}), nil
+	}
+	if err := validateProductID(id); err != nil {
+		result := map[string]interface{}{
+			"success":    false,
+			"error":      "Invalid product ID",
+			"product_id": id,
+			"message":    err.Error(),
+		}
+		if productIDPattern != nil {
+			result["pattern"] = productIDPattern.String()
+		}
+		return jsonError(result), nil
+	}
+
+	price, ok := args["price"].(float64)
+	if !ok || math.IsNaN(price) || math.IsInf(price, 0) || price < 0 {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "price must be a number zero or greater",
+			"product_id": id,
+			"price":      args["price"],
+		}), nil
+	}
+
+	stock := 0
+	if v, ok := args["stock"].(float64); ok {
+		if v != float64(int(v)) || v < 0 {
+			return jsonError(map[string]interface{}{
+				"success":    false,
+				"error":      "stock must be a non-negative integer",
+				"product_id": id,
+				"stock":      v,
+			}), nil
+		}
+		stock = int(v)
+	}
+	maxPerOrder := 0
+	if v, ok := args["max_per_order"].(float64); ok {
+		if v != float64(int(v)) || v < 0 {
+			return jsonError(map[string]interface{}{
+				"success":       false,
+				"error":         "max_per_order must be a non-negative integer",
+				"product_id":    id,
+				"max_per_order": v,
+			}), nil
+		}
+		maxPerOrder = int(v)
+	}
+	var attributes map[string]string
+	if v, present := args["attributes"]; present {
+		parsed, err := parseAttributes(v)
+		if err != nil {
+			return jsonError(map[string]interface{}{
+				"success":    false,
+				"error":      err.Error(),
+				"product_id": id,
+			}), nil
+		}
+		attributes = parsed
+	}
+	imageURL, _ := args["image_url"].(string)
+	if imageURL != "" {
+		if err := validateImageURL(imageURL); err != nil {
+			return jsonError(map[string]interface{}{
+				"success":    false,
+				"error":      err.Error(),
+				"product_id": id,
+			}), nil
+		}
+	}
+	category, _ := args["category"].(string)
+
+	product := Product{
+		ID:          id,
+		Name:        name,
+		Price:       roundMoney(price),
+		Category:    category,
+		Stock:       stock,
+		MaxPerOrder: maxPerOrder,
+		Attributes:  attributes,
+		ImageURL:    imageURL,
+	}
+	if err := catalog.Add(product); err != nil {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "Product ID already exists",
+			"product_id": id,
+		}), nil
+	}
+
+	auditLog.record(ctx, "add_product", product.ID, nil, product)
+
+	return jsonResult(map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"product":       product,
+		"message":       localize(ctx, "product_added", product.Name, product.ID, money(product.Price)),
+	}), nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "product_id": {"type": "string"}
+	  },
+	  "required": ["product_id"]
+	}
+*/
+func removeProductHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	productID, ok := args["product_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("product_id is not a string")
+	}
+
+	removed, existed := catalog.Remove(productID)
+	if !existed {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"existed":    false,
+			"error":      "Product not found",
+			"product_id": productID,
+		}), nil
+	}
+
+	auditLog.record(ctx, "remove_product", removed.ID, removed, nil)
+
+	return jsonResult(map[string]interface{}{
+		"success": true,
+		"existed": true,
+		"product": removed,
+		"message": localize(ctx, "product_removed", removed.Name, removed.ID),
+	}), nil
+}
+
+// parseCatalog validates a list of product objects as sent to import_catalog
+// or served from a catalog URL. It collects every problem, rather than
+// stopping at the first, so they can all be fixed in one go.
+func parseCatalog(items []interface{}) ([]Product, []string) {
+	products := make([]Product, 0, len(items))
+	var problems []string
+	seen := make(map[string]int)
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("products[%d]: not an object", i))
+			continue
+		}
+
+		id, _ := fields["id"].(string)
+		name, _ := fields["name"].(string)
+		category, _ := fields["category"].(string)
+		if err := validateProductID(id); err != nil {
+			problems = append(problems, fmt.Sprintf("products[%d]: %v", i, err))
+		} else if first, dup := seen[id]; dup {
+			problems = append(problems, fmt.Sprintf("products[%d]: duplicate ID %s, first used by products[%d]", i, id, first))
+		} else {
+			seen[id] = i
+		}
+		if name == "" {
+			problems = append(problems, fmt.Sprintf("products[%d]: name is required", i))
+		}
+
+		price, ok := fields["price"].(float64)
+		if !ok || math.IsNaN(price) || math.IsInf(price, 0) || price < 0 {
+			problems = append(problems, fmt.Sprintf("products[%d]: price must be a number zero or greater", i))
+		}
+
+		stock := 0
+		if v, present := fields["stock"]; present {
+			n, ok := v.(float64)
+			if !ok || n != float64(int(n)) || n < 0 {
+				problems = append(problems, fmt.Sprintf("products[%d]: stock must be a non-negative integer", i))
+			}
+			stock = int(n)
+		}
+
+		weight := 0.0
+		if v, present := fields["weight"]; present {
+			w, ok := v.(float64)
+			if !ok || math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+				problems = append(problems, fmt.Sprintf("products[%d]: weight must be a number zero or greater", i))
+			}
+			weight = w
+		}
+
+		maxPerOrder := 0
+		if v, present := fields["max_per_order"]; present {
+			n, ok := v.(float64)
+			if !ok || n != float64(int(n)) || n < 0 {
+				problems = append(problems, fmt.Sprintf("products[%d]: max_per_order must be a non-negative integer", i))
+			}
+			maxPerOrder = int(n)
+		}
+
+		var attributes map[string]string
+		if v, present := fields["attributes"]; present {
+			parsed, err := parseAttributes(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("products[%d]: %v", i, err))
+			}
+			attributes = parsed
+		}
+
+		imageURL := ""
+		if v, present := fields["image_url"]; present {
+			s, ok := v.(string)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("products[%d]: image_url must be a string", i))
+			} else if s != "" {
+				if err := validateImageURL(s); err != nil {
+					problems = append(problems, fmt.Sprintf("products[%d]: %v", i, err))
+				}
+			}
+			imageURL = s
+		}
+
+		var availableFrom time.Time
+		if v, present := fields["available_from"]; present {
+			s, ok := v.(string)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("products[%d]: available_from must be a string", i))
+			} else if s != "" {
+				t, err := parseReleaseDate(s)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("products[%d]: %v", i, err))
+				}
+				availableFrom = t
+			}
+		}
+
+		products = append(products, Product{
+			ID:            id,
+			Name:          name,
+			Price:         roundMoney(price),
+			Category:      category,
+			Stock:         stock,
+			Weight:        weight,
+			MaxPerOrder:   maxPerOrder,
+			Attributes:    attributes,
+			ImageURL:      imageURL,
+			AvailableFrom: availableFrom,
+		})
+	}
+	if len(products) == 0 && len(problems) == 0 {
+		problems = append(problems, "products must contain at least one product")
+	}
+
+	return products, problems
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "products": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "properties": {
+	          "id": {"type": "string"},
+	          "name": {"type": "string"},
+	          "price": {"type": "number"},
+	          "category": {"type": "string"},
+	          "stock": {"type": "integer"},
+	          "weight": {"type": "number"},
+	          "max_per_order": {"type": "integer"},
+	          "attributes": {"type": "object"},
+	          "image_url": {"type": "string"},
+	          "available_from": {"type": "string"}
+	        },
+	        "required": ["id", "name", "price"]
+	      }
+	    }
+	  },
+	  "required": ["products"]
+	}
+*/
+func importCatalogHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	items, ok := req.GetArguments()["products"].([]interface{})
+	if !ok {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "products must be an array",
+			"message": localize(ctx, "catalog_not_array"),
+		}), nil
+	}
+
+	// Validate every product before touching the catalog
+	products, problems := parseCatalog(items)
+	if len(problems) > 0 {
+		return jsonError(map[string]interface{}{
+			"success":       false,
+			"error":         "Invalid catalog",
+			"problems":      problems,
+			"problem_count": len(problems),
+			"message":       localize(ctx, "catalog_invalid", len(problems)),
+		}), nil
+	}
+
+	previous := catalog.Replace(products)
+	auditLog.record(ctx, "import_catalog", "catalog", previous, len(products))
+
+	return jsonResult(map[string]interface{}{
+		"success":        true,
+		"product_count":  len(products),
+		"previous_count": previous,
+		"message":        localize(ctx, "catalog_imported", len(products), previous),
+	}), nil
+}
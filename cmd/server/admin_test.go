@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAddProduct(t *testing.T) {
+	useCatalog(t, testProducts)
+
+	tests := []struct {
+		name  string
+		args  map[string]interface{}
+		error string
+	}{
+		{"new ID", map[string]interface{}{"id": "9", "name": "Headphones", "price": 150.0, "stock": 20.0}, ""},
+		{"duplicate ID", map[string]interface{}{"id": "1", "name": "Another laptop", "price": 900.0}, "Product ID already exists"},
+		{"missing name", map[string]interface{}{"id": "10", "price": 10.0}, "id and name are required"},
+		{"negative price", map[string]interface{}{"id": "10", "name": "Cable", "price": -1.0}, "price must be a number zero or greater"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callTool(t, addProductHandler, tt.args)
+			if tt.error == "" {
+				if result["success"] != true {
+					t.Fatalf("got %v, want success", result)
+				}
+				return
+			}
+			if result["success"] != false || result["error"] != tt.error {
+				t.Fatalf("got %v, want error %q", result, tt.error)
+			}
+		})
+	}
+
+	// The duplicate must not replace the original product
+	if product, _ := catalog.Get("1"); product.Name != "Laptop" || product.Price != 1000 {
+		t.Errorf("product 1 is %+v after a duplicate add, want the original Laptop", product)
+	}
+	if product, ok := catalog.Get("9"); !ok || product.Stock != 20 {
+		t.Errorf("product 9 is %+v, %v, want the added Headphones with stock 20", product, ok)
+	}
+}
+
+func TestRemoveProduct(t *testing.T) {
+	useCatalog(t, testProducts)
+
+	result := callTool(t, removeProductHandler, map[string]interface{}{"product_id": "2"})
+	if result["success"] != true || result["existed"] != true {
+		t.Fatalf("removing product 2 got %v, want success with existed true", result)
+	}
+	if _, ok := catalog.Get("2"); ok {
+		t.Error("product 2 is still in the catalog")
+	}
+
+	result = callTool(t, removeProductHandler, map[string]interface{}{"product_id": "2"})
+	if result["success"] != false || result["existed"] != false || result["error"] != "Product not found" {
+		t.Errorf("removing product 2 again got %v, want Product not found with existed false", result)
+	}
+	if got := len(catalog.List()); got != 2 {
+		t.Errorf("catalog has %d products, want 2", got)
+	}
+}
+
+func TestImportCatalogReleaseDates(t *testing.T) {
+	useCatalog(t, testProducts)
+
+	result := callTool(t, importCatalogHandler, map[string]interface{}{"products": []interface{}{
+		map[string]interface{}{"id": "1", "name": "Laptop", "price": 1000.0, "available_from": "2099-01-01"},
+		map[string]interface{}{"id": "2", "name": "Smartwatch", "price": 250.0, "available_from": "2099-06-01T06:00:00+08:00"},
+		map[string]interface{}{"id": "3", "name": "Tablet", "price": 300.0, "available_from": "next spring"},
+	}})
+	problems, _ := result["problems"].([]interface{})
+	if len(problems) != 1 || !strings.Contains(problems[0].(string), "products[2]: available_from") {
+		t.Fatalf("got %v, want one available_from problem for products[2]", result)
+	}
+
+	callTool(t, importCatalogHandler, map[string]interface{}{"products": []interface{}{
+		map[string]interface{}{"id": "1", "name": "Laptop", "price": 1000.0, "available_from": "2099-01-01"},
+		map[string]interface{}{"id": "2", "name": "Smartwatch", "price": 250.0, "available_from": "2099-06-01T06:00:00+08:00"},
+	}})
+	// 06:00 in Taipei is still the previous day in UTC
+	for id, want := range map[string]string{"1": "2099-01-01", "2": "2099-05-31"} {
+		details := callTool(t, getProductDetailsHandler, map[string]interface{}{"product_id": id})
+		if details["available"] != false || details["available_from"] != want {
+			t.Errorf("product %s got %v, want it unreleased until %s", id, details, want)
+		}
+	}
+}
+
+func TestImportCatalogErrorMessages(t *testing.T) {
+	useCatalog(t, testProducts)
+
+	for _, locale := range []string{localeEN, localeZhTW} {
+		t.Run(locale, func(t *testing.T) {
+			setVar(t, &defaultLocale, locale)
+
+			result := callTool(t, importCatalogHandler, map[string]interface{}{"products": "Laptop"})
+			if want := messageCatalog[locale]["catalog_not_array"]; result["success"] != false || result["message"] != want {
+				t.Errorf("products as a string got %v, want message %q", result, want)
+			}
+
+			result = callTool(t, importCatalogHandler, map[string]interface{}{"products": []interface{}{"Laptop"}})
+			if want := fmt.Sprintf(messageCatalog[locale]["catalog_invalid"], 1); result["message"] != want {
+				t.Errorf("invalid product got %v, want message %q", result, want)
+			}
+		})
+	}
+}
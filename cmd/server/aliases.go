@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// productAliases maps a product ID to the Chinese and English names shoppers
+// commonly use for it. STORE_PRODUCT_ALIASES adds to or moves entries.
+var productAliases = map[string][]string{
+	"1": {"筆電", "筆記型電腦", "電腦", "laptop"},
+	"2": {"智慧型手機", "手機", "smartphone"},
+	"3": {"平板", "平板電腦", "tablet"},
+	"4": {"智慧手錶", "手錶", "smartwatch"},
+}
+
+// normalizeAlias makes alias lookups ignore case and surrounding spaces
+func normalizeAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+// parseProductAliases parses a list such as "筆電=1,notebook=1,電話=2" into
+// product IDs by alias
+func parseProductAliases(spec string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		alias, id, ok := strings.Cut(entry, "=")
+		alias, id = strings.TrimSpace(alias), strings.TrimSpace(id)
+		if !ok || alias == "" || id == "" {
+			return nil, fmt.Errorf("%q is not alias=product_id", entry)
+		}
+		aliases[alias] = id
+	}
+	return aliases, nil
+}
+
+// setProductAliases points each alias at its product, removing it from the
+// product it belonged to before so every alias names exactly one product
+func setProductAliases(aliases map[string]string) {
+	for alias, id := range aliases {
+		key := normalizeAlias(alias)
+		for other, names := range productAliases {
+			kept := names[:0:0]
+			for _, name := range names {
+				if normalizeAlias(name) != key {
+					kept = append(kept, name)
+				}
+			}
+			productAliases[other] = kept
+		}
+		productAliases[id] = append(productAliases[id], alias)
+	}
+}
+
+// resolveAlias finds the product a shopper means by a name, trying its ID,
+// then its catalog name ignoring case, then the alias table. It also reports
+// which of the three matched.
+func resolveAlias(name string) (Product, string, bool) {
+	key := normalizeAlias(name)
+	if key == "" {
+		return Product{}, "", false
+	}
+	if product, ok := catalog.Get(strings.TrimSpace(name)); ok {
+		return product, "id", true
+	}
+	for _, product := range catalog.List() {
+		if normalizeAlias(product.Name) == key {
+			return product, "name", true
+		}
+	}
+	for id, names := range productAliases {
+		for _, alias := range names {
+			if normalizeAlias(alias) != key {
+				continue
+			}
+			if product, ok := catalog.Get(id); ok {
+				return product, "alias", true
+			}
+		}
+	}
+	return Product{}, "", false
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "alias": {"type": "string"}
+	  },
+	  "required": ["alias"]
+	}
+*/
+func resolveAliasHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	alias, ok := args["alias"].(string)
+	if !ok {
+		return nil, fmt.Errorf("alias is not a string")
+	}
+
+	product, matchedOn, ok := resolveAlias(alias)
+	if !ok {
+		errorResult := map[string]interface{}{
+			"success": false,
+			"error":   "Alias not found",
+			"alias":   alias,
+			"message": localize(ctx, "alias_not_found", alias),
+		}
+		if suggestions := suggestProducts(alias); len(suggestions) > 0 {
+			errorResult["suggestions"] = suggestions
+			errorResult["message"] = localize(ctx, "product_suggestions", alias, suggestions[0].ProductName, suggestions[0].ProductID)
+		}
+		return jsonError(errorResult), nil
+	}
+
+	// Products without aliases report an empty list rather than null
+	aliases := productAliases[product.ID]
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	return jsonResult(map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"alias":         alias,
+		"matched_on":    matchedOn,
+		"product_id":    product.ID,
+		"product_name":  product.Name,
+		"price":         roundMoney(product.Price),
+		"aliases":       aliases,
+		"message":       localize(ctx, "alias_resolved", alias, product.Name, product.ID),
+	}), nil
+}
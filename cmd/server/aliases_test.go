@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// useAliases gives the test its own copy of the alias table
+func useAliases(t *testing.T, aliases map[string][]string) {
+	t.Helper()
+	table := make(map[string][]string, len(aliases))
+	for id, names := range aliases {
+		table[id] = append([]string(nil), names...)
+	}
+	setVar(t, &productAliases, table)
+}
+
+func TestParseProductAliases(t *testing.T) {
+	tests := []struct {
+		spec string
+		want map[string]string
+		err  bool
+	}{
+		{"notebook=1, 電話 = 2,", map[string]string{"notebook": "1", "電話": "2"}, false},
+		{"", map[string]string{}, false},
+		{"notebook", nil, true},
+		{"=1", nil, true},
+		{"notebook=", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseProductAliases(tt.spec)
+		if (err != nil) != tt.err || (!tt.err && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("parseProductAliases(%q) = %v, %v, want %v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestSetProductAliasesMovesAlias(t *testing.T) {
+	useAliases(t, map[string][]string{"1": {"筆電", "電腦"}, "3": {"平板"}})
+
+	// 電腦 moves from the laptop to the tablet; matching ignores case
+	setProductAliases(map[string]string{"電腦": "3", "Notebook": "1"})
+
+	want := map[string][]string{"1": {"筆電", "Notebook"}, "3": {"平板", "電腦"}}
+	if !reflect.DeepEqual(productAliases, want) {
+		t.Errorf("aliases are %v, want %v", productAliases, want)
+	}
+}
+
+func TestResolveAlias(t *testing.T) {
+	useCatalog(t, testProducts)
+	useAliases(t, map[string][]string{"1": {"筆電", "notebook"}})
+
+	tests := []struct {
+		name      string
+		productID string
+		matchedOn string
+	}{
+		{"1", "1", "id"},
+		{"筆電", "1", "alias"},
+		{" NoteBook ", "1", "alias"},
+		{"tablet", "3", "name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callTool(t, resolveAliasHandler, map[string]interface{}{"alias": tt.name})
+			if result["product_id"] != tt.productID || result["matched_on"] != tt.matchedOn {
+				t.Errorf("got %v, want product %s matched on %s", result, tt.productID, tt.matchedOn)
+			}
+		})
+	}
+
+	result := callTool(t, resolveAliasHandler, map[string]interface{}{"alias": "耳機"})
+	if result["error"] != "Alias not found" {
+		t.Errorf("unknown alias got %v, want Alias not found", result)
+	}
+}
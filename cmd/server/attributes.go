@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// parseAttributes converts an attributes argument into a product attribute
+// map; every value must be a string
+func parseAttributes(v interface{}) (map[string]string, error) {
+	raw, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("attributes must be an object of strings")
+	}
+	attributes := make(map[string]string, len(raw))
+	for name, value := range raw {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("attribute %s must be a string", name)
+		}
+		attributes[name] = s
+	}
+	return attributes, nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "product_id": {"type": "string"}
+	  },
+	  "required": ["product_id"]
+	}
+*/
+func getProductDetailsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	productID, ok := args["product_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("product_id is not a string")
+	}
+
+	product, ok := catalog.Get(productID)
+	if !ok {
+		errorResult := map[string]interface{}{
+			"success":    false,
+			"error":      "Product not found",
+			"product_id": productID,
+		}
+		if suggestions := suggestProducts(productID); len(suggestions) > 0 {
+			errorResult["suggestions"] = suggestions
+			errorResult["message"] = localize(ctx, "product_suggestions", productID, suggestions[0].ProductName, suggestions[0].ProductID)
+		}
+		return jsonError(errorResult), nil
+	}
+
+	// List attribute names in order so the model can see what is known
+	attributes := make(map[string]string, len(product.Attributes))
+	names := make([]string, 0, len(product.Attributes))
+	for name, value := range product.Attributes {
+		attributes[name] = value
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	available := product.available(time.Now())
+	result := map[string]interface{}{
+		"success":         true,
+		"tax_inclusive":   pricesIncludeTax,
+		"currency":        storeCurrency,
+		"product_id":      product.ID,
+		"product_name":    product.Name,
+		"price":           roundMoney(product.Price),
+		"category":        product.Category,
+		"stock":           product.Stock,
+		"weight":          product.Weight,
+		"max_per_order":   product.maxPerOrder(),
+		"available":       available,
+		"attributes":      attributes,
+		"attribute_names": names,
+		"message":         localize(ctx, "product_details", product.Name, product.ID, len(names)),
+	}
+	if product.ImageURL != "" {
+		result["image_url"] = product.ImageURL
+	}
+	if !available {
+		result["preorder"] = true
+		result["available_from"] = product.releaseDate()
+	}
+	return jsonResult(result), nil
+}
+
+// attributeValue looks up an attribute by name, ignoring case
+func (p Product) attributeValue(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for key, value := range p.Attributes {
+		if strings.ToLower(key) == name {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// splitMeasure splits a value such as "10.9 inch" or "16GB" into its number
+// and unit
+func splitMeasure(value string) (float64, string, bool) {
+	value = strings.TrimSpace(value)
+	end := 0
+	for end < len(value) && (value[end] >= '0' && value[end] <= '9' || value[end] == '.') {
+		end++
+	}
+	n, err := strconv.ParseFloat(value[:end], 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return n, strings.ToLower(strings.TrimSpace(value[end:])), true
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "product_id_a": {"type": "string"},
+	    "product_id_b": {"type": "string"},
+	    "attribute": {"type": "string"}
+	  },
+	  "required": ["product_id_a", "product_id_b", "attribute"]
+	}
+*/
+func compareByAttributeHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	idA, _ := args["product_id_a"].(string)
+	idB, _ := args["product_id_b"].(string)
+	attribute, _ := args["attribute"].(string)
+	if strings.TrimSpace(attribute) == "" {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "attribute is required",
+		}), nil
+	}
+
+	products := make([]Product, 0, 2)
+	for _, id := range []string{idA, idB} {
+		product, ok := catalog.Get(id)
+		if !ok {
+			errorResult := map[string]interface{}{
+				"success":    false,
+				"error":      "Product not found",
+				"product_id": id,
+			}
+			if suggestions := suggestProducts(id); len(suggestions) > 0 {
+				errorResult["suggestions"] = suggestions
+				errorResult["message"] = localize(ctx, "product_suggestions", id, suggestions[0].ProductName, suggestions[0].ProductID)
+			}
+			return jsonError(errorResult), nil
+		}
+		products = append(products, product)
+	}
+	a, b := products[0], products[1]
+	valueA, hasA := a.attributeValue(attribute)
+	valueB, hasB := b.attributeValue(attribute)
+
+	if !hasA && !hasB {
+		return jsonError(map[string]interface{}{
+			"success":     false,
+			"error":       "Attribute not found",
+			"attribute":   attribute,
+			"product_ids": []string{a.ID, b.ID},
+			"message":     localize(ctx, "attribute_missing_both", a.Name, b.Name, attribute),
+		}), nil
+	}
+
+	entry := func(p Product, value string, has bool) map[string]interface{} {
+		e := map[string]interface{}{
+			"product_id":   p.ID,
+			"product_name": p.Name,
+			"has_value":    has,
+		}
+		if has {
+			e["value"] = value
+		}
+		return e
+	}
+	result := map[string]interface{}{
+		"success":   true,
+		"attribute": attribute,
+		"products":  []map[string]interface{}{entry(a, valueA, hasA), entry(b, valueB, hasB)},
+	}
+
+	switch {
+	case !hasA:
+		result["note"] = fmt.Sprintf("%s does not list %s", a.Name, attribute)
+		result["message"] = localize(ctx, "attribute_missing_one", attribute, b.Name, valueB, a.Name)
+	case !hasB:
+		result["note"] = fmt.Sprintf("%s does not list %s", b.Name, attribute)
+		result["message"] = localize(ctx, "attribute_missing_one", attribute, a.Name, valueA, b.Name)
+	default:
+		result["message"] = localize(ctx, "attribute_compared", attribute, a.Name, valueA, b.Name, valueB)
+
+		// Values with the same unit, such as two screen sizes in inches,
+		// can be ranked without the model parsing them
+		numA, unitA, okA := splitMeasure(valueA)
+		numB, unitB, okB := splitMeasure(valueB)
+		if okA && okB && unitA == unitB {
+			result["comparable"] = true
+			switch {
+			case numA > numB:
+				result["larger_product_id"] = a.ID
+			case numB > numA:
+				result["larger_product_id"] = b.ID
+			default:
+				result["equal"] = true
+			}
+		} else {
+			result["comparable"] = false
+		}
+	}
+	return jsonResult(result), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetProductDetails(t *testing.T) {
+	useCatalog(t, []Product{
+		{ID: "1", Name: "Laptop", Price: 1000, Category: "computers", Stock: 10, MaxPerOrder: 5,
+			Attributes: map[string]string{"ram": "16GB", "color": "silver"}},
+	})
+
+	result := callTool(t, getProductDetailsHandler, map[string]interface{}{"product_id": "1"})
+	if result["category"] != "computers" || result["max_per_order"] != 5.0 || result["available"] != true {
+		t.Errorf("got %v, want the laptop's category, order limit and availability", result)
+	}
+	wantAttributes := map[string]interface{}{"ram": "16GB", "color": "silver"}
+	if !reflect.DeepEqual(result["attributes"], wantAttributes) {
+		t.Errorf("attributes are %v, want %v", result["attributes"], wantAttributes)
+	}
+	if names := result["attribute_names"]; !reflect.DeepEqual(names, []interface{}{"color", "ram"}) {
+		t.Errorf("attribute_names are %v, want them sorted", names)
+	}
+
+	missing := callTool(t, getProductDetailsHandler, map[string]interface{}{"product_id": "99"})
+	if missing["error"] != "Product not found" {
+		t.Errorf("unknown product got %v, want Product not found", missing)
+	}
+}
+
+func TestAddProductAttributes(t *testing.T) {
+	useCatalog(t, testProducts)
+
+	result := callTool(t, addProductHandler, map[string]interface{}{
+		"id": "9", "name": "Headphones", "price": 150.0,
+		"attributes": map[string]interface{}{"color": "white", "battery": 30.0},
+	})
+	if result["error"] != "attribute battery must be a string" {
+		t.Fatalf("got %v, want the numeric attribute rejected", result)
+	}
+	if _, ok := catalog.Get("9"); ok {
+		t.Fatal("the product was added despite its invalid attributes")
+	}
+
+	callTool(t, addProductHandler, map[string]interface{}{
+		"id": "9", "name": "Headphones", "price": 150.0,
+		"attributes": map[string]interface{}{"color": "white"},
+	})
+	if product, _ := catalog.Get("9"); product.Attributes["color"] != "white" {
+		t.Errorf("product 9 has attributes %v, want color white", product.Attributes)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// AuditEntry records a single operation that changed the store's data
+type AuditEntry struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Actor     string      `json:"actor"`
+	Action    string      `json:"action"`
+	Target    string      `json:"target"`
+	OldValue  interface{} `json:"old_value,omitempty"`
+	NewValue  interface{} `json:"new_value,omitempty"`
+}
+
+// auditLogStore is an append-only, in-memory log of mutating operations
+type auditLogStore struct {
+	mu      sync.RWMutex
+	entries []AuditEntry
+}
+
+var auditLog = &auditLogStore{}
+
+// record appends an entry attributed to the session of the tool call
+func (l *auditLogStore) record(ctx context.Context, action, target string, oldValue, newValue interface{}) {
+	entry := AuditEntry{
+		Timestamp: time.Now(),
+		Actor:     sessionID(ctx, nil),
+		Action:    action,
+		Target:    target,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// between returns the entries recorded in [since, until], oldest first. A
+// zero time leaves that end of the range open.
+func (l *auditLogStore) between(since, until time.Time) []AuditEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := make([]AuditEntry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		if !since.IsZero() && entry.Timestamp.Before(since) {
+			continue
+		}
+		if !until.IsZero() && entry.Timestamp.After(until) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "since": {"type": "string"},
+	    "until": {"type": "string"}
+	  }
+	}
+*/
+func getAuditLogHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+
+	var bounds [2]time.Time
+	for i, name := range []string{"since", "until"} {
+		v, _ := args[name].(string)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return jsonError(map[string]interface{}{
+				"success": false,
+				"error":   fmt.Sprintf("Invalid %s time", name),
+				name:      v,
+				"message": localize(ctx, "invalid_time", name),
+			}), nil
+		}
+		bounds[i] = t
+	}
+
+	entries := auditLog.between(bounds[0], bounds[1])
+	return jsonResult(map[string]interface{}{
+		"success":     true,
+		"entries":     entries,
+		"entry_count": len(entries),
+		"message":     localize(ctx, "audit_entries", len(entries)),
+	}), nil
+}
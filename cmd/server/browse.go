@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// lessProductID orders product IDs numerically when both are numbers, so "2"
+// comes before "10", and as strings otherwise
+func lessProductID(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+// extremePriceProduct returns the product in the category (any category when
+// empty) whose price beats every other by better, lowest ID winning ties
+func extremePriceProduct(category string, better func(a, b float64) bool) (Product, bool) {
+	var best Product
+	found := false
+	for _, p := range catalog.List() {
+		if category != "" && !strings.EqualFold(p.Category, category) {
+			continue
+		}
+		if !found || better(p.Price, best.Price) || (p.Price == best.Price && lessProductID(p.ID, best.ID)) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "category": {"type": "string"}
+	  }
+	}
+*/
+func getCheapestHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return extremePriceResult(ctx, req, "cheapest_product", func(a, b float64) bool { return a < b })
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "category": {"type": "string"}
+	  }
+	}
+*/
+func getMostExpensiveHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return extremePriceResult(ctx, req, "most_expensive_product", func(a, b float64) bool { return a > b })
+}
+
+// extremePriceResult finds the product for get_cheapest or get_most_expensive
+// and reports it with the message under messageKey
+func extremePriceResult(ctx context.Context, req mcp.CallToolRequest, messageKey string, better func(a, b float64) bool) (*mcp.CallToolResult, error) {
+	category, _ := req.GetArguments()["category"].(string)
+	category = strings.TrimSpace(category)
+
+	product, ok := extremePriceProduct(category, better)
+	if !ok {
+		result := map[string]interface{}{
+			"success": false,
+			"error":   "No products found",
+			"message": localize(ctx, "no_products"),
+		}
+		if category != "" {
+			result["category"] = category
+			result["message"] = localize(ctx, "no_products_in_category", category)
+		}
+		return jsonError(result), nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"product_id":    product.ID,
+		"product_name":  product.Name,
+		"price":         roundMoney(product.Price),
+		"category":      product.Category,
+		"message":       localize(ctx, messageKey, product.Name, product.ID, money(product.Price)),
+	}), nil
+}
+
+const (
+	// defaultListLimit is the page size of list_products without a limit
+	defaultListLimit = 20
+	// maxListLimit bounds the page size so one call cannot dump a large
+	// catalog
+	maxListLimit = 100
+)
+
+// productSortKeys lists the sort_by values of list_products
+var productSortKeys = []string{"price_asc", "price_desc", "name"}
+
+// productSorts orders products for each sort_by value. Products that compare
+// equal keep their catalog order.
+var productSorts = map[string]func(a, b Product) bool{
+	"price_asc":  func(a, b Product) bool { return a.Price < b.Price },
+	"price_desc": func(a, b Product) bool { return a.Price > b.Price },
+	"name":       func(a, b Product) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) },
+}
+
+// pageArgument reads an optional non-negative integer paging argument
+func pageArgument(args map[string]interface{}, name string, fallback int) (int, bool) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return fallback, true
+	}
+	n, ok := v.(float64)
+	if !ok || n != float64(int(n)) || n < 0 {
+		return 0, false
+	}
+	return int(n), true
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "limit": {"type": "integer"},
+	    "offset": {"type": "integer"},
+	    "sort_by": {"type": "string", "enum": ["price_asc", "price_desc", "name"]}
+	  }
+	}
+*/
+func listProductsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+
+	limit, ok := pageArgument(args, "limit", defaultListLimit)
+	if !ok || limit < 1 || limit > maxListLimit {
+		return jsonError(map[string]interface{}{
+			"success":   false,
+			"error":     "Invalid limit",
+			"limit":     args["limit"],
+			"max_limit": maxListLimit,
+			"message":   localize(ctx, "invalid_limit", maxListLimit),
+		}), nil
+	}
+	offset, ok := pageArgument(args, "offset", 0)
+	if !ok {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "Invalid offset",
+			"offset":  args["offset"],
+			"message": localize(ctx, "invalid_offset"),
+		}), nil
+	}
+
+	sortBy, _ := args["sort_by"].(string)
+	less, ok := productSorts[sortBy]
+	if sortBy != "" && !ok {
+		return jsonError(map[string]interface{}{
+			"success":         false,
+			"error":           "Unknown sort key",
+			"sort_by":         sortBy,
+			"valid_sort_keys": productSortKeys,
+			"message":         localize(ctx, "unknown_sort_key", sortBy, strings.Join(productSortKeys, ", ")),
+		}), nil
+	}
+
+	// Sort the whole catalog before paging so pages follow on from each other
+	products := catalog.List()
+	if less != nil {
+		sort.SliceStable(products, func(i, j int) bool { return less(products[i], products[j]) })
+	}
+	total := len(products)
+	start := min(offset, total)
+	end := min(start+limit, total)
+
+	now := time.Now()
+	page := make([]map[string]interface{}, 0, end-start)
+	for _, p := range products[start:end] {
+		page = append(page, map[string]interface{}{
+			"product_id":   p.ID,
+			"product_name": p.Name,
+			"price":        roundMoney(p.Price),
+			"category":     p.Category,
+			"stock":        p.Stock,
+			"available":    p.available(now),
+		})
+	}
+
+	result := map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"currency":      storeCurrency,
+		"products":      page,
+		"count":         len(page),
+		"total":         total,
+		"offset":        offset,
+		"limit":         limit,
+		"next_offset":   nil,
+	}
+	if sortBy != "" {
+		result["sort_by"] = sortBy
+	}
+	if len(page) == 0 {
+		result["message"] = localize(ctx, "product_page_empty", offset, total)
+	} else {
+		result["message"] = localize(ctx, "product_page", start+1, end, total)
+	}
+	// next_offset stays null on the last page so callers know to stop
+	if end < total {
+		result["next_offset"] = end
+	}
+	return jsonResult(result), nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pageIDs returns the product IDs on a list_products page
+func pageIDs(result map[string]interface{}) []string {
+	products, _ := result["products"].([]interface{})
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		product, _ := p.(map[string]interface{})
+		id, _ := product["product_id"].(string)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestListProductsSortBy(t *testing.T) {
+	useCatalog(t, []Product{
+		{ID: "1", Name: "laptop", Price: 1000},
+		{ID: "2", Name: "Smartphone", Price: 500},
+		{ID: "3", Name: "Tablet", Price: 300},
+		{ID: "4", Name: "Case", Price: 300},
+	})
+
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{"", []string{"1", "2", "3", "4"}},
+		// Tablet and Case tie on price and keep their catalog order
+		{"price_asc", []string{"3", "4", "2", "1"}},
+		{"price_desc", []string{"1", "2", "3", "4"}},
+		{"name", []string{"4", "1", "2", "3"}},
+	}
+	for _, tt := range tests {
+		name := tt.sortBy
+		if name == "" {
+			name = "catalog order"
+		}
+		t.Run(name, func(t *testing.T) {
+			args := map[string]interface{}{}
+			if tt.sortBy != "" {
+				args["sort_by"] = tt.sortBy
+			}
+			if got := pageIDs(callTool(t, listProductsHandler, args)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListProductsSortedPagesFollowOn(t *testing.T) {
+	useCatalog(t, []Product{
+		{ID: "1", Name: "Laptop", Price: 1000},
+		{ID: "2", Name: "Smartphone", Price: 500},
+		{ID: "3", Name: "Tablet", Price: 300},
+	})
+
+	var got []string
+	args := map[string]interface{}{"limit": 2.0, "sort_by": "price_asc"}
+	for {
+		result := callTool(t, listProductsHandler, args)
+		got = append(got, pageIDs(result)...)
+		next, ok := result["next_offset"].(float64)
+		if !ok {
+			break
+		}
+		args["offset"] = next
+	}
+	if want := []string{"3", "2", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages hold %v, want %v", got, want)
+	}
+}
+
+func TestListProductsRejectsUnknownSortKey(t *testing.T) {
+	useCatalog(t, testProducts)
+
+	result := callTool(t, listProductsHandler, map[string]interface{}{"sort_by": "rating"})
+	if result["error"] != "Unknown sort key" || !reflect.DeepEqual(result["valid_sort_keys"], []interface{}{"price_asc", "price_desc", "name"}) {
+		t.Errorf("got %v, want Unknown sort key listing the valid keys", result)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// productIDPattern, when set, is the format every product ID must match in
+// full, such as a SKU scheme. Nil accepts any non-empty ID.
+var productIDPattern *regexp.Regexp
+
+// validateProductID checks an ID against productIDPattern
+func validateProductID(id string) error {
+	if id == "" {
+		return fmt.Errorf("product ID is empty")
+	}
+	if productIDPattern != nil && !productIDPattern.MatchString(id) {
+		return fmt.Errorf("product ID %q does not match the pattern %s", id, productIDPattern)
+	}
+	return nil
+}
+
+// Catalog is a thread-safe store of the products available in the store
+type Catalog struct {
+	mu       sync.RWMutex
+	products []Product
+	// index maps a product ID to its position in products. It is rebuilt
+	// whenever products are added, removed or replaced; price and stock
+	// updates change products in place and keep the positions.
+	index map[string]int
+}
+
+// NewCatalog creates a catalog holding a copy of the given products
+func NewCatalog(products []Product) *Catalog {
+	c := &Catalog{products: make([]Product, len(products))}
+	copy(c.products, products)
+	c.reindex()
+	return c
+}
+
+// Get returns the product with the given ID
+func (c *Catalog) Get(id string) (Product, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	idx := c.indexOf(id)
+	if idx < 0 {
+		return Product{}, false
+	}
+	return c.products[idx], true
+}
+
+// List returns a snapshot of all products in catalog order
+func (c *Catalog) List() []Product {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	products := make([]Product, len(c.products))
+	copy(products, c.products)
+	return products
+}
+
+// Add inserts a new product, rejecting duplicate IDs
+func (c *Catalog) Add(product Product) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.indexOf(product.ID) >= 0 {
+		return fmt.Errorf("product with ID %s already exists", product.ID)
+	}
+	c.products = append(c.products, product)
+	c.index[product.ID] = len(c.products) - 1
+	return nil
+}
+
+// Replace swaps the whole catalog for the given products in one step and
+// returns the number of products it held before
+func (c *Catalog) Replace(products []Product) int {
+	replacement := make([]Product, len(products))
+	copy(replacement, products)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := len(c.products)
+	c.products = replacement
+	c.reindex()
+	return previous
+}
+
+// Remove deletes the product with the given ID and returns it
+func (c *Catalog) Remove(id string) (Product, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := c.indexOf(id)
+	if idx < 0 {
+		return Product{}, false
+	}
+	removed := c.products[idx]
+	c.products = append(c.products[:idx], c.products[idx+1:]...)
+	c.reindex()
+	return removed, true
+}
+
+// UpdatePrice sets the price of a product and records the change in the
+// price history. It returns the previous price.
+func (c *Catalog) UpdatePrice(id string, price float64) (float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx := c.indexOf(id)
+	if idx < 0 {
+		return 0, fmt.Errorf("product with ID %s not found", id)
+	}
+
+	oldPrice := c.products[idx].Price
+	c.products[idx].Price = price
+	priceHistory.record(id, oldPrice, price)
+	return oldPrice, nil
+}
+
+// InsufficientStockError reports the first line item that could not be
+// fulfilled from the current stock
+type InsufficientStockError struct {
+	ProductID   string
+	ProductName string
+	Requested   int
+	Available   int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for %s (ID %s): requested %d, available %d",
+		e.ProductName, e.ProductID, e.Requested, e.Available)
+}
+
+// CheckStock reports whether every line can be fulfilled from the current
+// stock without changing it. It returns the stock each product would have left.
+func (c *Catalog) CheckStock(lines []lineItem) (map[string]int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.remainingStock(lines)
+}
+
+// DecrementStock removes the requested quantities from stock as a single
+// transaction. Every line is validated before anything is applied, so either
+// all lines are decremented or none are. It returns the stock left per product.
+func (c *Catalog) DecrementStock(lines []lineItem) (map[string]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	remaining, err := c.remainingStock(lines)
+	if err != nil {
+		return nil, err
+	}
+	for id, stock := range remaining {
+		c.products[c.indexOf(id)].Stock = stock
+	}
+	return remaining, nil
+}
+
+// RestoreStock puts the quantities of cancelled order lines back in stock as
+// a single transaction. Products no longer in the catalog are skipped. It
+// returns the new stock of every product that was restocked.
+func (c *Catalog) RestoreStock(items []OrderLine) map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	restocked := make(map[string]int, len(items))
+	for _, item := range items {
+		idx := c.indexOf(item.ProductID)
+		if idx < 0 {
+			continue
+		}
+		c.products[idx].Stock += item.Quantity
+		restocked[item.ProductID] = c.products[idx].Stock
+	}
+	return restocked
+}
+
+// remainingStock validates the lines against the current stock and returns
+// what each product would have left. Callers must hold the lock.
+func (c *Catalog) remainingStock(lines []lineItem) (map[string]int, error) {
+	// Sum the requested quantity per product so that repeated lines for the
+	// same product are checked against the stock together
+	requested := make(map[string]int)
+	for _, line := range lines {
+		requested[line.Product.ID] += line.Quantity
+	}
+
+	remaining := make(map[string]int, len(requested))
+	for _, line := range lines {
+		idx := c.indexOf(line.Product.ID)
+		if idx < 0 {
+			return nil, fmt.Errorf("product with ID %s not found", line.Product.ID)
+		}
+		p := c.products[idx]
+		if requested[p.ID] > p.Stock {
+			return nil, &InsufficientStockError{
+				ProductID:   p.ID,
+				ProductName: p.Name,
+				Requested:   requested[p.ID],
+				Available:   p.Stock,
+			}
+		}
+		remaining[p.ID] = p.Stock - requested[p.ID]
+	}
+	return remaining, nil
+}
+
+// indexOf returns the position of the product in the catalog, or -1. Callers
+// must hold the lock.
+func (c *Catalog) indexOf(id string) int {
+	if idx, ok := c.index[id]; ok {
+		return idx
+	}
+	return -1
+}
+
+// reindex rebuilds the ID index after products were added, removed or
+// replaced. Callers must hold the write lock.
+func (c *Catalog) reindex() {
+	c.index = make(map[string]int, len(c.products))
+	for i, p := range c.products {
+		c.index[p.ID] = i
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAddProductRejectsIDsOutsideThePattern(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &productIDPattern, regexp.MustCompile(`^(?:[A-Z]{3}-[0-9]{4})$`))
+
+	result := callTool(t, addProductHandler, map[string]interface{}{"id": "9", "name": "Headphones", "price": 150.0})
+	if result["error"] != "Invalid product ID" {
+		t.Fatalf("got %v, want Invalid product ID", result)
+	}
+}
+
+func TestImportCatalogRejectsIDsOutsideThePattern(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &productIDPattern, regexp.MustCompile(`^(?:[A-Z]{3}-[0-9]{4})$`))
+
+	result := callTool(t, importCatalogHandler, map[string]interface{}{"products": []interface{}{
+		map[string]interface{}{"id": "LAP-0001", "name": "Laptop", "price": 1000.0},
+		map[string]interface{}{"id": "2", "name": "Smartphone", "price": 500.0},
+	}})
+	problems, _ := result["problems"].([]interface{})
+	if len(problems) != 1 || !strings.Contains(problems[0].(string), "products[1]") {
+		t.Fatalf("got %v, want one problem for products[1]", result)
+	}
+	if ids := productIDs(); ids != "1,2,3" {
+		t.Errorf("catalog holds %s after a rejected import, want it unchanged", ids)
+	}
+}
+
+func TestLoadCatalogChecksIDPattern(t *testing.T) {
+	setVar(t, &productIDPattern, regexp.MustCompile(`^(?:[A-Z]{3}-[0-9]{4})$`))
+	setVar(t, &seedFixtures, false)
+
+	t.Run("built-in products", func(t *testing.T) {
+		useCatalog(t, []Product{{ID: "LAP-0001", Name: "Laptop"}, {ID: "2", Name: "Smartphone"}})
+		setVar(t, &catalogURL, "")
+
+		loadCatalog()
+		if ids := productIDs(); ids != "LAP-0001" {
+			t.Errorf("catalog holds %s, want only LAP-0001", ids)
+		}
+	})
+
+	t.Run("fetched catalog", func(t *testing.T) {
+		useCatalog(t, []Product{{ID: "LAP-0001", Name: "Laptop"}})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"id": "PHN-0002", "name": "Smartphone", "price": 500}, {"id": "3", "name": "Tablet", "price": 300}]`))
+		}))
+		defer srv.Close()
+		setVar(t, &catalogURL, srv.URL)
+
+		// One bad ID rejects the whole fetched catalog
+		loadCatalog()
+		if ids := productIDs(); ids != "LAP-0001" {
+			t.Errorf("catalog holds %s, want the built-in LAP-0001 kept", ids)
+		}
+	})
+}
+
+// productIDs lists the IDs in the catalog, comma separated
+func productIDs() string {
+	var ids []string
+	for _, p := range catalog.List() {
+		ids = append(ids, p.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+// linearGet looks a product up the way Get did before the catalog had an
+// index, for comparison in BenchmarkCatalogGet
+func (c *Catalog) linearGet(id string) (Product, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, p := range c.products {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Product{}, false
+}
+
+func BenchmarkCatalogGet(b *testing.B) {
+	for _, size := range []int{4, 100, 10000} {
+		c := NewCatalog(largeCatalog(size))
+		// Look up every product in turn so the average position is measured
+		ids := make([]string, size)
+		for i := range ids {
+			ids[i] = strconv.Itoa(i + 1)
+		}
+
+		b.Run(fmt.Sprintf("index/products=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, ok := c.Get(ids[i%size]); !ok {
+					b.Fatal("product not found")
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("linear_scan/products=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, ok := c.linearGet(ids[i%size]); !ok {
+					b.Fatal("product not found")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxCatalogProblems is how many validation problems are logged when a
+// fetched catalog is rejected
+const maxCatalogProblems = 5
+
+var (
+	// catalogURL serves the catalog as a JSON array of products, in the same
+	// form import_catalog accepts; empty keeps the built-in catalog
+	catalogURL = ""
+	// catalogFetchTimeout bounds the whole request, including the body
+	catalogFetchTimeout = 10 * time.Second
+)
+
+// fetchCatalog downloads and validates the catalog at url. Products are
+// checked exactly as import_catalog checks them, and any problem rejects
+// the whole catalog.
+func fetchCatalog(url string, timeout time.Duration) ([]Product, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var items []interface{}
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, fmt.Errorf("not a JSON array of products: %v", err)
+	}
+
+	products, problems := parseCatalog(items)
+	if len(problems) > 0 {
+		if len(problems) > maxCatalogProblems {
+			problems = append(problems[:maxCatalogProblems], fmt.Sprintf("and %d more", len(problems)-maxCatalogProblems))
+		}
+		return nil, fmt.Errorf("invalid catalog: %s", strings.Join(problems, "; "))
+	}
+	return products, nil
+}
+
+// loadCatalog fills the catalog at startup and logs where it came from: the
+// catalog URL, the test fixtures or the built-in defaults. A catalog URL that
+// cannot be fetched or fails validation leaves the other source in place.
+// Built-in products whose IDs do not match productIDPattern are left out.
+func loadCatalog() {
+	source, count := "defaults", len(catalog.List())
+	if seedFixtures {
+		catalog.Replace(fixtureProducts)
+		source, count = "fixtures", len(fixtureProducts)
+	}
+
+	if catalogURL != "" {
+		products, err := fetchCatalog(catalogURL, catalogFetchTimeout)
+		if err != nil {
+			log.Printf("Catalog not loaded from %s, using the %s: %v", catalogURL, source, err)
+		} else {
+			catalog.Replace(products)
+			source, count = catalogURL, len(products)
+		}
+	}
+
+	// A fetched catalog was checked against the pattern by parseCatalog; the
+	// defaults and fixtures are only checked here
+	if productIDPattern != nil && source != catalogURL {
+		for _, p := range catalog.List() {
+			if err := validateProductID(p.ID); err != nil {
+				catalog.Remove(p.ID)
+				count--
+				log.Printf("Catalog product %s left out: %v", p.Name, err)
+			}
+		}
+	}
+
+	log.Printf("Loaded %d products from %s", count, source)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoadCatalogFromURL(t *testing.T) {
+	setVar(t, &seedFixtures, false)
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"valid catalog", http.StatusOK, `[{"id": "7", "name": "Monitor", "price": 250, "stock": 4}, {"id": "8", "name": "Keyboard", "price": 80}]`, "7,8"},
+		{"not found", http.StatusNotFound, `[{"id": "7", "name": "Monitor", "price": 250}]`, "1,2,3"},
+		{"not an array", http.StatusOK, `{"products": []}`, "1,2,3"},
+		{"invalid product", http.StatusOK, `[{"id": "7", "name": "Monitor", "price": 250}, {"id": "8", "price": -1}]`, "1,2,3"},
+		{"empty array", http.StatusOK, `[]`, "1,2,3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCatalog(t, testProducts)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			setVar(t, &catalogURL, srv.URL)
+
+			loadCatalog()
+			if ids := productIDs(); ids != tt.want {
+				t.Errorf("catalog holds %s, want %s", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCatalogTimesOut(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &seedFixtures, false)
+	setVar(t, &catalogFetchTimeout, 50*time.Millisecond)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+	setVar(t, &catalogURL, srv.URL)
+
+	loadCatalog()
+	if ids := productIDs(); ids != "1,2,3" {
+		t.Errorf("catalog holds %s after a timed out fetch, want the defaults kept", ids)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var (
+	// maxConcurrentCalls bounds the tool calls running at once across all
+	// clients; 0 means no limit
+	maxConcurrentCalls = 0
+	// callQueueTimeout is how long a call waits for a free slot before it is
+	// turned away; 0 turns calls away as soon as every slot is taken
+	callQueueTimeout = 5 * time.Second
+)
+
+// callSlots holds one token per running call; nil until
+// startConcurrencyLimit sets the limit
+var callSlots chan struct{}
+
+// startConcurrencyLimit creates the call slots for maxConcurrentCalls. It
+// runs once after the configuration is read.
+func startConcurrencyLimit() {
+	if maxConcurrentCalls > 0 {
+		callSlots = make(chan struct{}, maxConcurrentCalls)
+	}
+}
+
+// acquireSlot waits up to callQueueTimeout for a free call slot and reports
+// whether it got one
+func acquireSlot(ctx context.Context) bool {
+	select {
+	case callSlots <- struct{}{}:
+		return true
+	default:
+	}
+	if callQueueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(callQueueTimeout)
+	defer timer.Stop()
+	select {
+	case callSlots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// concurrencyMiddleware queues calls beyond maxConcurrentCalls and turns
+// them away with a server busy error when no slot frees up in time
+func concurrencyMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if callSlots == nil {
+			return next(ctx, req)
+		}
+		if !acquireSlot(ctx) {
+			return jsonError(map[string]interface{}{
+				"success":              false,
+				"error":                "Server busy",
+				"tool":                 req.Params.Name,
+				"max_concurrent_calls": maxConcurrentCalls,
+				"message":              localize(ctx, "server_busy", maxConcurrentCalls),
+			}), nil
+		}
+		defer func() { <-callSlots }()
+		return next(ctx, req)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// blockingHandler returns a handler that reports each call on started and
+// then waits for release to be closed
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		started <- struct{}{}
+		<-release
+		return jsonResult(map[string]interface{}{"success": true}), nil
+	}
+}
+
+// useConcurrencyLimit sets up call slots for limit calls for the test
+func useConcurrencyLimit(t *testing.T, limit int, queueTimeout time.Duration) {
+	setVar(t, &maxConcurrentCalls, limit)
+	setVar(t, &callQueueTimeout, queueTimeout)
+	setVar(t, &callSlots, nil)
+	startConcurrencyLimit()
+}
+
+func TestConcurrencyLimitTurnsAwayExtraCalls(t *testing.T) {
+	const limit = 2
+	useConcurrencyLimit(t, limit, 0)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := concurrencyMiddleware(blockingHandler(started, release))
+
+	var wg sync.WaitGroup
+	for i := 0; i < limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var req mcp.CallToolRequest
+			handler(context.Background(), req)
+		}()
+	}
+	for i := 0; i < limit; i++ {
+		<-started
+	}
+
+	// Every slot is taken, so further calls are turned away at once
+	for i := 0; i < 3; i++ {
+		result := callTool(t, handler, nil)
+		if result["error"] != "Server busy" || result["max_concurrent_calls"] != float64(limit) {
+			t.Errorf("extra call %d got %v, want Server busy", i, result)
+		}
+	}
+
+	close(release)
+	wg.Wait()
+
+	// The slots are free again once the running calls finish
+	go func() { <-started }()
+	if result := callTool(t, handler, nil); result["success"] != true {
+		t.Errorf("call after release got %v, want success", result)
+	}
+}
+
+func TestConcurrencyLimitQueuesCalls(t *testing.T) {
+	useConcurrencyLimit(t, 1, 5*time.Second)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := concurrencyMiddleware(blockingHandler(started, release))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var req mcp.CallToolRequest
+		handler(context.Background(), req)
+	}()
+	<-started
+
+	// The second call waits for the first to free its slot
+	queued := make(chan *mcp.CallToolResult)
+	go func() {
+		var req mcp.CallToolRequest
+		res, _ := handler(context.Background(), req)
+		queued <- res
+	}()
+	close(release)
+	<-started
+	if result := decodeResult(t, <-queued); result["success"] != true {
+		t.Errorf("queued call got %v, want success", result)
+	}
+	<-done
+}
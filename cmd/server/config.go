@@ -0,0 +1,292 @@
+package main
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadConfig applies server settings from the environment. The client starts
+// the server as a subprocess, so environment variables reach it unchanged.
+func loadConfig() {
+	if v := os.Getenv("STORE_ADMIN"); v != "" {
+		adminEnabled, _ = strconv.ParseBool(v)
+	}
+
+	if v := os.Getenv("STORE_SEED"); v != "" {
+		seedFixtures, _ = strconv.ParseBool(v)
+	}
+
+	catalogURL = os.Getenv("STORE_CATALOG_URL")
+
+	if v := os.Getenv("STORE_CATALOG_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			catalogFetchTimeout = d
+		} else {
+			log.Printf("Invalid STORE_CATALOG_TIMEOUT %q, using %v", v, catalogFetchTimeout)
+		}
+	}
+
+	switch mode := strings.ToLower(os.Getenv("STORE_ROUNDING")); mode {
+	case "", "half_up":
+		moneyRounding = RoundHalfUp
+	case "half_even", "bankers":
+		moneyRounding = RoundHalfEven
+	default:
+		log.Printf("Unknown STORE_ROUNDING %q, using half_up", mode)
+	}
+
+	if v := os.Getenv("STORE_CURRENCY"); v != "" {
+		code := strings.ToUpper(strings.TrimSpace(v))
+		if _, ok := currencyDecimals[code]; ok {
+			storeCurrency = code
+		} else {
+			log.Printf("Unsupported STORE_CURRENCY %q, using %s", v, storeCurrency)
+		}
+	}
+
+	currencySymbolOverride = os.Getenv("STORE_CURRENCY_SYMBOL")
+
+	if v := os.Getenv("STORE_IDEMPOTENCY_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil && ttl > 0 {
+			idempotencyKeys.ttl = ttl
+		} else {
+			log.Printf("Invalid STORE_IDEMPOTENCY_TTL %q, using %v", v, idempotencyKeys.ttl)
+		}
+	}
+
+	if v := os.Getenv("STORE_TOTAL_TOKEN_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil && ttl > 0 {
+			totalTokenTTL = ttl
+		} else {
+			log.Printf("Invalid STORE_TOTAL_TOKEN_TTL %q, using %v", v, totalTokenTTL)
+		}
+	}
+
+	if v := os.Getenv("STORE_MAX_LINE_QUANTITY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxLineQuantity = n
+		} else {
+			log.Printf("Invalid STORE_MAX_LINE_QUANTITY %q, using %d", v, maxLineQuantity)
+		}
+	}
+
+	if v := os.Getenv("STORE_MAX_ORDER_QUANTITY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxOrderQuantity = n
+		} else {
+			log.Printf("Invalid STORE_MAX_ORDER_QUANTITY %q, using %d", v, maxOrderQuantity)
+		}
+	}
+
+	if v := os.Getenv("STORE_MAX_LINE_ITEMS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxLineItems = n
+		} else {
+			log.Printf("Invalid STORE_MAX_LINE_ITEMS %q, using %d", v, maxLineItems)
+		}
+	}
+
+	if v := os.Getenv("STORE_MIN_ORDER_VALUE"); v != "" {
+		if value, err := strconv.ParseFloat(v, 64); err == nil && value >= 0 {
+			minOrderValue = value
+		} else {
+			log.Printf("Invalid STORE_MIN_ORDER_VALUE %q, using %v", v, minOrderValue)
+		}
+	}
+
+	switch mode := strings.ToLower(os.Getenv("STORE_MISSING_QUANTITY")); mode {
+	case "", "default":
+		defaultMissingQuantity = true
+	case "strict":
+		defaultMissingQuantity = false
+	default:
+		log.Printf("Unknown STORE_MISSING_QUANTITY %q, using default", mode)
+	}
+
+	if v := os.Getenv("STORE_POINTS_PER_DOLLAR"); v != "" {
+		if rate, err := strconv.ParseFloat(v, 64); err == nil && rate >= 0 {
+			pointsPerDollar = rate
+		} else {
+			log.Printf("Invalid STORE_POINTS_PER_DOLLAR %q, using %v", v, pointsPerDollar)
+		}
+	}
+
+	if v := os.Getenv("STORE_POINT_VALUE"); v != "" {
+		if value, err := strconv.ParseFloat(v, 64); err == nil && value > 0 {
+			pointValue = value
+		} else {
+			log.Printf("Invalid STORE_POINT_VALUE %q, using %v", v, pointValue)
+		}
+	}
+
+	if v := os.Getenv("STORE_PRICES_INCLUDE_TAX"); v != "" {
+		if inclusive, err := strconv.ParseBool(v); err == nil {
+			pricesIncludeTax = inclusive
+		} else {
+			log.Printf("Invalid STORE_PRICES_INCLUDE_TAX %q, using %v", v, pricesIncludeTax)
+		}
+	}
+
+	if v := os.Getenv("STORE_TAX_RATE"); v != "" {
+		if rate, err := strconv.ParseFloat(v, 64); err == nil && rate >= 0 && rate < 1 {
+			taxRate = rate
+		} else {
+			log.Printf("Invalid STORE_TAX_RATE %q, using %v", v, taxRate)
+		}
+	}
+
+	if v := os.Getenv("STORE_MAX_RESULT_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxResultSize = n
+		} else {
+			log.Printf("Invalid STORE_MAX_RESULT_SIZE %q, using %d", v, maxResultSize)
+		}
+	}
+
+	if v := os.Getenv("STORE_ORDER_WEBHOOK_URL"); v != "" {
+		if err := validateWebhookURL(v); err == nil {
+			orderWebhookURL = v
+		} else {
+			log.Printf("Invalid STORE_ORDER_WEBHOOK_URL %q, not sending order webhooks: %v", v, err)
+		}
+	}
+
+	if v := os.Getenv("STORE_BUSINESS_HOURS"); v != "" {
+		if hours, err := parseBusinessHours(v); err == nil {
+			storeHours = &hours
+		} else {
+			log.Printf("Invalid STORE_BUSINESS_HOURS %q, staying open: %v", v, err)
+		}
+	}
+
+	if v := os.Getenv("STORE_TIMEZONE"); v != "" {
+		if loc, err := time.LoadLocation(v); err == nil {
+			storeTimezone = loc
+		} else {
+			log.Printf("Invalid STORE_TIMEZONE %q, using %s: %v", v, storeTimezone, err)
+		}
+	}
+
+	if v := os.Getenv("STORE_MAX_CONCURRENT_CALLS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxConcurrentCalls = n
+		} else {
+			log.Printf("Invalid STORE_MAX_CONCURRENT_CALLS %q, using %d", v, maxConcurrentCalls)
+		}
+	}
+
+	if v := os.Getenv("STORE_CALL_QUEUE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			callQueueTimeout = d
+		} else {
+			log.Printf("Invalid STORE_CALL_QUEUE_TIMEOUT %q, using %v", v, callQueueTimeout)
+		}
+	}
+
+	if v := os.Getenv("STORE_SESSION_TTL"); v != "" {
+		if ttl, err := time.ParseDuration(v); err == nil && ttl >= 0 {
+			sessions.ttl = ttl
+		} else {
+			log.Printf("Invalid STORE_SESSION_TTL %q, using %v", v, sessions.ttl)
+		}
+	}
+
+	if v := os.Getenv("STORE_RATE_LIMITS"); v != "" {
+		if limits, err := parseRateLimits(v); err == nil {
+			toolRateLimits = limits
+		} else {
+			log.Printf("Invalid STORE_RATE_LIMITS %q, not rate limiting: %v", v, err)
+		}
+	}
+
+	if v := os.Getenv("STORE_RATE_LIMIT_BY_SESSION"); v != "" {
+		if bySession, err := strconv.ParseBool(v); err == nil {
+			rateLimitBySession = bySession
+		} else {
+			log.Printf("Invalid STORE_RATE_LIMIT_BY_SESSION %q, using %v", v, rateLimitBySession)
+		}
+	}
+
+	requestLogPath = os.Getenv("STORE_REQUEST_LOG")
+
+	if v := os.Getenv("STORE_REQUEST_LOG_MAX_SIZE"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			requestLogMaxSize = n
+		} else {
+			log.Printf("Invalid STORE_REQUEST_LOG_MAX_SIZE %q, using %d", v, requestLogMaxSize)
+		}
+	}
+
+	if v := os.Getenv("STORE_REQUEST_LOG_BACKUPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			requestLogBackups = n
+		} else {
+			log.Printf("Invalid STORE_REQUEST_LOG_BACKUPS %q, using %d", v, requestLogBackups)
+		}
+	}
+
+	if v := os.Getenv("STORE_REQUEST_LOG_FIELDS"); v != "" {
+		requestLogFields = make(map[string]bool)
+		for _, field := range strings.Split(v, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				requestLogFields[field] = true
+			}
+		}
+	}
+
+	if v := os.Getenv("STORE_LOCALE"); v != "" {
+		if locale := normalizeLocale(v); locale != "" {
+			defaultLocale = locale
+		} else {
+			log.Printf("Unsupported STORE_LOCALE %q, using %s", v, defaultLocale)
+		}
+	}
+
+	if v := os.Getenv("STORE_SHIPPING_ZONES"); v != "" {
+		if zones, err := parseShippingZones(v); err == nil {
+			shippingZones = zones
+			if _, ok := zones[defaultShippingZone]; !ok {
+				log.Printf("STORE_SHIPPING_ZONES has no %s zone, shipping_cost will require a destination", defaultShippingZone)
+			}
+		} else {
+			log.Printf("Invalid STORE_SHIPPING_ZONES %q, using the default zones: %v", v, err)
+		}
+	}
+
+	if v := os.Getenv("STORE_PRODUCT_ALIASES"); v != "" {
+		if aliases, err := parseProductAliases(v); err == nil {
+			setProductAliases(aliases)
+		} else {
+			log.Printf("Invalid STORE_PRODUCT_ALIASES %q, using the default aliases: %v", v, err)
+		}
+	}
+
+	if v := os.Getenv("STORE_TOOLS"); v != "" {
+		enabledTools = parseToolList(v)
+	}
+
+	if v := os.Getenv("STORE_NUMBER_FORMAT"); v != "" {
+		name := v
+		if locale := normalizeLocale(v); locale != "" {
+			name = locale
+		}
+		if _, ok := numberFormats[name]; ok {
+			numberFormatOverride = name
+		} else {
+			log.Printf("Unsupported STORE_NUMBER_FORMAT %q, following the message locale", v)
+		}
+	}
+
+	if v := os.Getenv("STORE_PRODUCT_ID_PATTERN"); v != "" {
+		// Anchor the pattern so it has to match the whole ID
+		if pattern, err := regexp.Compile(`^(?:` + v + `)$`); err == nil {
+			productIDPattern = pattern
+		} else {
+			log.Printf("Invalid STORE_PRODUCT_ID_PATTERN %q, accepting any product ID: %v", v, err)
+		}
+	}
+}
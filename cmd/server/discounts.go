@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// discount is a single promotional rule applied by apply_discounts
+type discount struct {
+	Type  string // "percentage" keeps Value% of the price (打X折), "flat" subtracts Value
+	Value float64
+}
+
+// apply returns the price after this discount
+func (d discount) apply(price float64) float64 {
+	if d.Type == "flat" {
+		return roundMoney(price - d.Value)
+	}
+	return roundMoney(price * (d.Value / 100))
+}
+
+// explainDiscount breaks an apply_discount calculation into its steps: the
+// percentage kept, the multiplication and its rounding, and the subtraction
+// giving the amount saved
+func explainDiscount(mode string, discountPercentage, keptPercentage, totalPrice, discountedPrice, savedAmount float64) map[string]interface{} {
+	decimals := moneyDecimals()
+	originalPrice := roundMoney(totalPrice)
+
+	keptStep := fmt.Sprintf("kept percentage = %g%% (mode keep)", keptPercentage)
+	if mode == "off" {
+		keptStep = fmt.Sprintf("kept percentage = 100%% - %g%% = %g%% (mode off)", discountPercentage, keptPercentage)
+	}
+	multiplier := keptPercentage / 100
+	exactPrice := totalPrice * multiplier
+
+	multiplyStep := fmt.Sprintf("discounted price = %.*f × %g = %.*f", decimals, totalPrice, multiplier, decimals, discountedPrice)
+	if math.Abs(exactPrice-discountedPrice) > 1e-9 {
+		multiplyStep = fmt.Sprintf("discounted price = %.*f × %g = %g, rounded to %.*f", decimals, totalPrice, multiplier, exactPrice, decimals, discountedPrice)
+	}
+
+	return map[string]interface{}{
+		"original_price":         originalPrice,
+		"kept_percentage":        keptPercentage,
+		"multiplier":             multiplier,
+		"exact_discounted_price": exactPrice,
+		"discounted_price":       discountedPrice,
+		"saved_amount":           savedAmount,
+		"steps": []string{
+			keptStep,
+			multiplyStep,
+			fmt.Sprintf("saved amount = %.*f - %.*f = %.*f", decimals, originalPrice, decimals, discountedPrice, decimals, savedAmount),
+		},
+	}
+}
+
+// parseDiscounts validates the discounts argument of apply_discounts
+func parseDiscounts(raw []interface{}) ([]discount, error) {
+	discounts := make([]discount, 0, len(raw))
+	for i, entry := range raw {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("discount %d is not an object", i+1)
+		}
+		typ, _ := m["type"].(string)
+		value, ok := m["value"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("discount %d has no numeric value", i+1)
+		}
+		switch typ {
+		case "percentage":
+			if value < 0 || value > 100 {
+				return nil, fmt.Errorf("discount %d percentage must be between 0 and 100", i+1)
+			}
+		case "flat":
+			if value < 0 {
+				return nil, fmt.Errorf("discount %d flat amount cannot be negative", i+1)
+			}
+		default:
+			return nil, fmt.Errorf("discount %d has unknown type %q", i+1, typ)
+		}
+		discounts = append(discounts, discount{Type: typ, Value: value})
+	}
+	return discounts, nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "total_price": {"type": "number"},
+	    "discounts": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "properties": {
+	          "type": {"type": "string", "enum": ["percentage", "flat"]},
+	          "value": {"type": "number"}
+	        },
+	        "required": ["type", "value"]
+	      }
+	    },
+	    "stacking": {"type": "string", "enum": ["sequential", "best"]}
+	  },
+	  "required": ["total_price", "discounts"]
+	}
+*/
+func applyDiscountsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	totalPrice, ok := args["total_price"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing total_price")
+	}
+	rawDiscounts, ok := args["discounts"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("discounts is not an array")
+	}
+	stacking, _ := args["stacking"].(string)
+	if stacking == "" {
+		stacking = "sequential"
+	}
+	if stacking != "sequential" && stacking != "best" {
+		return jsonError(map[string]interface{}{
+			"success":  false,
+			"error":    "Unknown stacking mode",
+			"stacking": stacking,
+		}), nil
+	}
+
+	discounts, err := parseDiscounts(rawDiscounts)
+	if err != nil {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "Invalid discount",
+			"message": err.Error(),
+		}), nil
+	}
+	if len(discounts) == 0 {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "At least one discount is required",
+		}), nil
+	}
+
+	originalPrice := roundMoney(totalPrice)
+
+	// With "best" only the single discount giving the lowest price is applied
+	if stacking == "best" {
+		best := discounts[0]
+		for _, d := range discounts[1:] {
+			if d.apply(originalPrice) < best.apply(originalPrice) {
+				best = d
+			}
+		}
+		discounts = []discount{best}
+	}
+
+	price := originalPrice
+	steps := make([]map[string]interface{}, 0, len(discounts))
+	for i, d := range discounts {
+		next := d.apply(price)
+		if next < 0 {
+			return jsonError(map[string]interface{}{
+				"success":        false,
+				"error":          "Discounts reduce the price below zero",
+				"step":           i + 1,
+				"price_before":   price,
+				"discount_type":  d.Type,
+				"discount_value": d.Value,
+			}), nil
+		}
+		steps = append(steps, map[string]interface{}{
+			"step":           i + 1,
+			"discount_type":  d.Type,
+			"discount_value": d.Value,
+			"price_before":   price,
+			"price_after":    next,
+			"saved_amount":   roundMoney(price - next),
+		})
+		price = next
+	}
+
+	savedAmount := roundMoney(originalPrice - price)
+	return jsonResult(map[string]interface{}{
+		"success":          true,
+		"tax_inclusive":    pricesIncludeTax,
+		"stacking":         stacking,
+		"original_price":   originalPrice,
+		"discounted_price": price,
+		"saved_amount":     savedAmount,
+		"applied":          steps,
+		"message":          localize(ctx, "discounts_applied", money(originalPrice), len(steps), money(price), money(savedAmount)),
+	}), nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import "time"
+
+// seedFixtures replaces the default catalog with fixtureProducts so tests
+// see the same products and stock on every run
+var seedFixtures = false
+
+// fixtureProducts is the fixed catalog loaded by -seed. Unlike the defaults it
+// never changes between releases and its preorder date is absolute, so
+// scripted tests can assert exact results. Each product covers one case:
+//
+//	F1 Widget       plain product with plenty of stock
+//	F2 Gadget       at most 2 per order and only 5 in stock
+//	F3 Gizmo        out of stock
+//	F4 Doohickey    cheap and light, for quantity and rounding checks
+//	F5 Future Phone preorder, released 2099-01-01
+var fixtureProducts = []Product{
+	{ID: "F1", Name: "Widget", Price: 10.0, Category: "tools", Stock: 100, Weight: 0.5},
+	{ID: "F2", Name: "Gadget", Price: 24.99, Category: "tools", Stock: 5, Weight: 1.2, MaxPerOrder: 2},
+	{ID: "F3", Name: "Gizmo", Price: 99.5, Category: "electronics", Stock: 0, Weight: 2.0},
+	{ID: "F4", Name: "Doohickey", Price: 0.35, Category: "electronics", Stock: 1000, Weight: 0.01},
+	{ID: "F5", Name: "Future Phone", Price: 799.0, Category: "electronics", Stock: 10, Weight: 0.2,
+		AvailableFrom: time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)},
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestFixtureProducts(t *testing.T) {
+	useCatalog(t, fixtureProducts)
+
+	// Each fixture covers the case listed next to it in fixtures.go
+	total := callTool(t, calculateTotalHandler, map[string]interface{}{"items": itemsArg("F1", 3, "F4", 10)})
+	if total["total_price"] != 33.5 {
+		t.Errorf("3 Widgets and 10 Doohickeys cost %v, want 33.5", total["total_price"])
+	}
+
+	overLimit := callTool(t, calculateTotalHandler, map[string]interface{}{"items": itemsArg("F2", 3)})
+	if names := errorNames(overLimit); len(names) != 1 || names[0] != "Product quantity exceeds its limit" {
+		t.Errorf("3 Gadgets got %v, want the per-order limit of 2 to reject them", overLimit)
+	}
+
+	outOfStock := callTool(t, placeOrderHandler, map[string]interface{}{"items": itemsArg("F3", 1)})
+	if outOfStock["error"] != "Insufficient stock" {
+		t.Errorf("ordering a Gizmo got %v, want it rejected as out of stock", outOfStock)
+	}
+
+	preorder := callTool(t, calculateTotalHandler, map[string]interface{}{"items": itemsArg("F5", 1), "allow_preorder": true})
+	if preorder["preorder"] != true || preorder["ships_on"] != "2099-01-01" {
+		t.Errorf("Future Phone got %v, want a preorder shipping on 2099-01-01", preorder)
+	}
+}
+
+func TestSeedFromEnvironment(t *testing.T) {
+	setVar(t, &seedFixtures, false)
+	t.Setenv("STORE_SEED", "1")
+
+	loadConfig()
+	if !seedFixtures {
+		t.Error("STORE_SEED=1 did not enable the fixtures")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// largeCatalog returns n products with IDs "1" to n
+func largeCatalog(n int) []Product {
+	products := make([]Product, n)
+	for i := range products {
+		products[i] = Product{
+			ID:    strconv.Itoa(i + 1),
+			Name:  fmt.Sprintf("Product %d", i+1),
+			Price: float64(i%500) + 0.99,
+			Stock: 1 << 30,
+		}
+	}
+	return products
+}
+
+// benchmarkTool runs a handler b.N times with the same arguments
+func benchmarkTool(b *testing.B, handler server.ToolHandlerFunc, args map[string]interface{}) {
+	b.Helper()
+	var req mcp.CallToolRequest
+	req.Params.Arguments = args
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		res, err := handler(ctx, req)
+		if err != nil || res.IsError {
+			b.Fatalf("call failed: %v %v", err, res.Content)
+		}
+	}
+}
+
+// manyItems builds an items argument of n lines, one unit each, cycling
+// through the first products of the catalog
+func manyItems(n, products int) []interface{} {
+	pairs := make([]interface{}, 0, 2*n)
+	for i := 0; i < n; i++ {
+		pairs = append(pairs, strconv.Itoa(i%products+1), 1)
+	}
+	return itemsArg(pairs...)
+}
+
+func BenchmarkGetPriceHandler(b *testing.B) {
+	useCatalog(b, testProducts)
+	benchmarkTool(b, getPriceHandler, map[string]interface{}{"product_id": "2"})
+}
+
+func BenchmarkCalculateTotalHandler(b *testing.B) {
+	useCatalog(b, testProducts)
+	setVar(b, &maxLineItems, 1000)
+
+	for _, n := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("items=%d", n), func(b *testing.B) {
+			benchmarkTool(b, calculateTotalHandler, map[string]interface{}{"items": manyItems(n, len(testProducts))})
+		})
+	}
+}
+
+func BenchmarkApplyDiscountHandler(b *testing.B) {
+	benchmarkTool(b, applyDiscountHandler, map[string]interface{}{
+		"total_price":         1299.99,
+		"discount_percentage": 85.0,
+	})
+}
+
+func BenchmarkLargeCatalog(b *testing.B) {
+	const size = 10000
+	useCatalog(b, largeCatalog(size))
+	setVar(b, &maxLineItems, 1000)
+
+	b.Run("get_price", func(b *testing.B) {
+		benchmarkTool(b, getPriceHandler, map[string]interface{}{"product_id": strconv.Itoa(size)})
+	})
+	b.Run("calculate_total/items=100", func(b *testing.B) {
+		benchmarkTool(b, calculateTotalHandler, map[string]interface{}{"items": manyItems(100, size)})
+	})
+	b.Run("calculate_total/items=1000", func(b *testing.B) {
+		benchmarkTool(b, calculateTotalHandler, map[string]interface{}{"items": manyItems(1000, size)})
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callTool runs a tool handler with the given arguments and decodes the
+// structured result it returns
+func callTool(t testing.TB, handler server.ToolHandlerFunc, args map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	var req mcp.CallToolRequest
+	req.Params.Arguments = args
+	res, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handler returned an error: %v", err)
+	}
+	return decodeResult(t, res)
+}
+
+// decodeResult decodes the structured JSON of a tool result
+func decodeResult(t testing.TB, res *mcp.CallToolResult) map[string]interface{} {
+	t.Helper()
+	text, ok := res.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("first content is %T, want text", res.Content[0])
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(text.Text), &result); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, text.Text)
+	}
+	return result
+}
+
+// useCatalog gives the test a catalog holding products and an empty order
+// store, and puts the previous ones back when it ends
+func useCatalog(t testing.TB, products []Product) {
+	t.Helper()
+	prevCatalog, prevOrders := catalog, orders
+	catalog = NewCatalog(products)
+	orders = &orderStore{orders: make(map[string]*Order)}
+	t.Cleanup(func() {
+		catalog, orders = prevCatalog, prevOrders
+	})
+}
+
+// setVar sets a package variable, such as a limit, for the rest of the test
+func setVar[T any](t testing.TB, v *T, value T) {
+	t.Helper()
+	prev := *v
+	*v = value
+	t.Cleanup(func() { *v = prev })
+}
+
+// itemsArg builds an items argument from product ID and quantity pairs, such
+// as itemsArg("1", 2, "2", 1)
+func itemsArg(pairs ...interface{}) []interface{} {
+	items := make([]interface{}, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		quantity := pairs[i+1]
+		if n, ok := quantity.(int); ok {
+			quantity = float64(n)
+		}
+		items = append(items, map[string]interface{}{"product_id": pairs[i], "quantity": quantity})
+	}
+	return items
+}
+
+// testProducts is a small catalog with plenty of stock and no per-product caps
+var testProducts = []Product{
+	{ID: "1", Name: "Laptop", Price: 1000, Stock: 10000},
+	{ID: "2", Name: "Smartphone", Price: 500, Stock: 10000},
+	{ID: "3", Name: "Tablet", Price: 300, Stock: 10000},
+}
+
+// errorNames returns the error of each problem in an Invalid items result
+func errorNames(result map[string]interface{}) []string {
+	problems, _ := result["errors"].([]interface{})
+	names := make([]string, 0, len(problems))
+	for _, p := range problems {
+		problem, _ := p.(map[string]interface{})
+		name, _ := problem["error"].(string)
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// businessHours is the daily window in which the store takes orders, as
+// offsets from midnight in storeTimezone. A window whose close is before its
+// open runs past midnight, as in 22:00-02:00.
+type businessHours struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+func (h businessHours) String() string {
+	return formatClock(h.Open) + "-" + formatClock(h.Close)
+}
+
+// formatClock formats an offset from midnight as HH:MM
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// parseClock parses a time of day such as 09:00 into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time such as 09:00", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// parseBusinessHours parses a window such as "09:00-18:00"
+func parseBusinessHours(spec string) (businessHours, error) {
+	open, closing, ok := strings.Cut(spec, "-")
+	if !ok {
+		return businessHours{}, fmt.Errorf("%q is not open-close, such as 09:00-18:00", spec)
+	}
+	var h businessHours
+	var err error
+	if h.Open, err = parseClock(open); err != nil {
+		return businessHours{}, err
+	}
+	if h.Close, err = parseClock(closing); err != nil {
+		return businessHours{}, err
+	}
+	if h.Open == h.Close {
+		return businessHours{}, fmt.Errorf("%q opens and closes at the same time", spec)
+	}
+	return h, nil
+}
+
+var (
+	// storeHours is when the store takes orders; nil keeps it always open
+	storeHours *businessHours
+	// storeTimezone is the timezone storeHours are in
+	storeTimezone = time.Local
+)
+
+// openOnlyTools are the customer tools that change state, so they are only
+// served while the store is open. Lookups, calculations and admin tools are
+// always available.
+var openOnlyTools = map[string]bool{
+	"place_order":   true,
+	"cancel_order":  true,
+	"earn_points":   true,
+	"redeem_points": true,
+	"wishlist_add":  true,
+}
+
+// sinceMidnight returns how long after midnight in storeTimezone t is
+func sinceMidnight(t time.Time) time.Duration {
+	t = t.In(storeTimezone)
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// storeOpen reports whether the store takes orders at now
+func storeOpen(now time.Time) bool {
+	if storeHours == nil {
+		return true
+	}
+	since := sinceMidnight(now)
+	if storeHours.Open < storeHours.Close {
+		return since >= storeHours.Open && since < storeHours.Close
+	}
+	return since >= storeHours.Open || since < storeHours.Close
+}
+
+// nextOpening returns when the store next opens after now
+func nextOpening(now time.Time) time.Time {
+	local := now.In(storeTimezone)
+	opens := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, storeTimezone).Add(storeHours.Open)
+	if !opens.After(now) {
+		opens = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, storeTimezone).Add(storeHours.Open)
+	}
+	return opens
+}
+
+// businessHoursMiddleware turns away calls to openOnlyTools outside business
+// hours with an error saying when the store opens again
+func businessHoursMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		now := time.Now()
+		if !openOnlyTools[req.Params.Name] || storeOpen(now) {
+			return next(ctx, req)
+		}
+
+		opens := nextOpening(now)
+		return jsonError(map[string]interface{}{
+			"success":        false,
+			"error":          "Store closed",
+			"tool":           req.Params.Name,
+			"business_hours": storeHours.String(),
+			"timezone":       storeTimezone.String(),
+			"opens_at":       opens.Format(time.RFC3339),
+			"message":        localize(ctx, "store_closed", storeHours.String(), storeTimezone.String(), opens.Format("2006-01-02 15:04")),
+		}), nil
+	}
+}
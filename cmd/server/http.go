@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// backendCheckTimeout bounds how long /healthz waits for the catalog
+const backendCheckTimeout = time.Second
+
+// catalogReachable reports whether the catalog answers within the timeout,
+// which catches a lock that is held indefinitely
+func catalogReachable() (int, bool) {
+	size := make(chan int, 1)
+	go func() { size <- len(catalog.List()) }()
+
+	select {
+	case n := <-size:
+		return n, true
+	case <-time.After(backendCheckTimeout):
+		return 0, false
+	}
+}
+
+// healthzHandler reports readiness for load balancers and orchestrators
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	catalogSize, reachable := catalogReachable()
+
+	status := http.StatusOK
+	state := "ok"
+	if !reachable {
+		status = http.StatusServiceUnavailable
+		state = "unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":            state,
+		"uptime_seconds":    int(time.Since(startTime).Seconds()),
+		"catalog_size":      catalogSize,
+		"backend":           "memory",
+		"backend_reachable": reachable,
+	})
+}
+
+// serveHTTP serves the MCP server over HTTP/SSE on addr, next to a /healthz
+// endpoint that is independent of the MCP protocol
+func serveHTTP(s *server.MCPServer, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.Handle("/", server.NewSSEServer(s))
+
+	log.Printf("Serving MCP over HTTP/SSE on %s (SSE endpoint /sse, health check /healthz)", addr)
+	return http.ListenAndServe(addr, mux)
+}
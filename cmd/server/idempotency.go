@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// idempotencySweepInterval is how often Do clears out expired keys
+const idempotencySweepInterval = time.Minute
+
+// idempotencyEntry records the order created for an idempotency key. While
+// the first call for the key is still placing its order, done is open and
+// orderID is empty.
+type idempotencyEntry struct {
+	orderID   string
+	expiresAt time.Time
+	done      chan struct{}
+}
+
+// idempotencyStore remembers which order each idempotency key produced so
+// retried place_order calls return the original order instead of a new one
+type idempotencyStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+var idempotencyKeys = &idempotencyStore{
+	ttl:     24 * time.Hour,
+	entries: make(map[string]*idempotencyEntry),
+}
+
+// Do returns the order ID already recorded for key, or runs place and records
+// the order ID it returns. Calls for the same key wait for the first one to
+// finish, so two concurrent retries cannot both place an order; calls for
+// other keys run alongside. An empty order ID from place means no order was
+// created and nothing is recorded, so the next call for the key tries again.
+func (s *idempotencyStore) Do(key string, place func() (string, error)) (orderID string, duplicate bool, err error) {
+	s.mu.Lock()
+	for {
+		now := time.Now()
+		if now.Sub(s.lastSweep) >= idempotencySweepInterval {
+			s.sweep(now)
+		}
+
+		entry, ok := s.entries[key]
+		if !ok || entry.done == nil && now.After(entry.expiresAt) {
+			break
+		}
+		if entry.done == nil {
+			s.mu.Unlock()
+			return entry.orderID, true, nil
+		}
+
+		// Another call is placing the order for this key; wait for it and
+		// look again, since it may have failed without recording one
+		done := entry.done
+		s.mu.Unlock()
+		<-done
+		s.mu.Lock()
+	}
+
+	entry := &idempotencyEntry{done: make(chan struct{})}
+	s.entries[key] = entry
+	s.mu.Unlock()
+
+	// Settle the entry even if place panics, so waiting calls are released
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if orderID == "" || err != nil {
+			delete(s.entries, key)
+		} else {
+			entry.orderID = orderID
+			entry.expiresAt = time.Now().Add(s.ttl)
+		}
+		close(entry.done)
+		entry.done = nil
+	}()
+
+	orderID, err = place()
+	return orderID, false, err
+}
+
+// sweep removes expired keys; keys whose order is still being placed stay.
+// The caller holds s.mu.
+func (s *idempotencyStore) sweep(now time.Time) {
+	for k, entry := range s.entries {
+		if entry.done == nil && now.After(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.lastSweep = now
+}
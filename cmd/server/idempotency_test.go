@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// useIdempotencyStore gives the test an empty idempotency store
+func useIdempotencyStore(t *testing.T) {
+	prev := idempotencyKeys
+	idempotencyKeys = &idempotencyStore{ttl: time.Hour, entries: make(map[string]*idempotencyEntry)}
+	t.Cleanup(func() { idempotencyKeys = prev })
+}
+
+func orderArgs(key string) map[string]interface{} {
+	return map[string]interface{}{
+		"items":           []interface{}{map[string]interface{}{"product_id": "1", "quantity": float64(1)}},
+		"idempotency_key": key,
+	}
+}
+
+func TestPlaceOrderSameKeyTwice(t *testing.T) {
+	useCatalog(t, []Product{{ID: "1", Name: "Laptop", Price: 1000, Stock: 10}})
+	useIdempotencyStore(t)
+
+	first := callTool(t, placeOrderHandler, orderArgs("retry-1"))
+	second := callTool(t, placeOrderHandler, orderArgs("retry-1"))
+
+	if first["duplicate"] != false || second["duplicate"] != true {
+		t.Fatalf("duplicate flags are %v and %v, want false then true", first["duplicate"], second["duplicate"])
+	}
+	if first["order_id"] != second["order_id"] {
+		t.Errorf("retry returned order %v, want the original %v", second["order_id"], first["order_id"])
+	}
+	if product, _ := catalog.Get("1"); product.Stock != 9 {
+		t.Errorf("stock is %d, want 9 after a single order", product.Stock)
+	}
+}
+
+func TestPlaceOrderSameKeyConcurrently(t *testing.T) {
+	useCatalog(t, []Product{{ID: "1", Name: "Laptop", Price: 1000, Stock: 100}})
+	useIdempotencyStore(t)
+
+	const retries = 20
+	var wg sync.WaitGroup
+	responses := make([]*mcp.CallToolResult, retries)
+	errs := make([]error, retries)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var req mcp.CallToolRequest
+			req.Params.Arguments = orderArgs("same")
+			responses[i], errs[i] = placeOrderHandler(context.Background(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	orderIDs := make([]interface{}, retries)
+	for i, res := range responses {
+		if errs[i] != nil {
+			t.Fatalf("place_order returned an error: %v", errs[i])
+		}
+		orderIDs[i] = decodeResult(t, res)["order_id"]
+	}
+	for _, id := range orderIDs {
+		if id != orderIDs[0] || id == nil {
+			t.Fatalf("concurrent calls got order IDs %v, want one order", orderIDs)
+		}
+	}
+	if product, _ := catalog.Get("1"); product.Stock != 99 {
+		t.Errorf("stock is %d, want 99 after a single order", product.Stock)
+	}
+}
+
+func TestIdempotencyOtherKeysDoNotWait(t *testing.T) {
+	store := &idempotencyStore{ttl: time.Hour, entries: make(map[string]*idempotencyEntry)}
+
+	// Hold the first key's order open while another key places its own
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go store.Do("slow", func() (string, error) {
+		close(started)
+		<-release
+		return "ORD-0001", nil
+	})
+	<-started
+
+	done := make(chan string)
+	go func() {
+		id, _, _ := store.Do("fast", func() (string, error) { return "ORD-0002", nil })
+		done <- id
+	}()
+	select {
+	case id := <-done:
+		if id != "ORD-0002" {
+			t.Errorf("fast key got %s, want ORD-0002", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a call for another key waited for the slow one")
+	}
+	close(release)
+}
+
+func TestIdempotencyFailedPlaceIsRetried(t *testing.T) {
+	store := &idempotencyStore{ttl: time.Hour, entries: make(map[string]*idempotencyEntry)}
+	var attempts atomic.Int32
+
+	store.Do("key", func() (string, error) {
+		attempts.Add(1)
+		return "", nil
+	})
+	id, duplicate, _ := store.Do("key", func() (string, error) {
+		attempts.Add(1)
+		return "ORD-0001", nil
+	})
+	if id != "ORD-0001" || duplicate || attempts.Load() != 2 {
+		t.Errorf("got %s duplicate=%v after %d attempts, want a fresh ORD-0001 after 2", id, duplicate, attempts.Load())
+	}
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	store := &idempotencyStore{ttl: time.Millisecond, entries: make(map[string]*idempotencyEntry)}
+
+	store.Do("key", func() (string, error) { return "ORD-0001", nil })
+	time.Sleep(5 * time.Millisecond)
+	id, duplicate, _ := store.Do("key", func() (string, error) { return "ORD-0002", nil })
+	if id != "ORD-0002" || duplicate {
+		t.Errorf("expired key returned %s duplicate=%v, want a new ORD-0002", id, duplicate)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// validateImageURL checks that a product image URL is an absolute http(s)
+// URL a client can load directly
+func validateImageURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("image_url %q is not a URL", raw)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("image_url %q must be an absolute http or https URL", raw)
+	}
+	return nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "product_id": {"type": "string"}
+	  },
+	  "required": ["product_id"]
+	}
+*/
+func getProductImageHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	productID, ok := args["product_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("product_id is not a string")
+	}
+
+	product, ok := catalog.Get(productID)
+	if !ok {
+		errorResult := map[string]interface{}{
+			"success":    false,
+			"error":      "Product not found",
+			"product_id": productID,
+		}
+		if suggestions := suggestProducts(productID); len(suggestions) > 0 {
+			errorResult["suggestions"] = suggestions
+			errorResult["message"] = localize(ctx, "product_suggestions", productID, suggestions[0].ProductName, suggestions[0].ProductID)
+		}
+		return jsonError(errorResult), nil
+	}
+
+	if product.ImageURL == "" {
+		return jsonError(map[string]interface{}{
+			"success":      false,
+			"error":        "Image not found",
+			"product_id":   product.ID,
+			"product_name": product.Name,
+			"message":      localize(ctx, "product_image_missing", product.Name),
+		}), nil
+	}
+
+	// The product name is the alt text, so it follows renames and imports
+	return jsonResult(map[string]interface{}{
+		"success":      true,
+		"product_id":   product.ID,
+		"product_name": product.Name,
+		"image_url":    product.ImageURL,
+		"alt_text":     product.Name,
+		"message":      localize(ctx, "product_image", product.Name),
+	}), nil
+}
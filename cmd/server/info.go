@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	serverName    = "Product Price Server"
+	serverVersion = "1.0.0"
+)
+
+// buildCommit is the git commit the binary was built from, set at build time
+// with -ldflags "-X main.buildCommit=<sha>"
+var buildCommit = "unknown"
+
+// startTime is when the server process started, used to report uptime
+var startTime = time.Now()
+
+// serverTransport is the transport the server is serving on
+var serverTransport = "stdio"
+
+func getServerInfoHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	uptime := time.Since(startTime)
+	return jsonResult(map[string]interface{}{
+		"success":        true,
+		"name":           serverName,
+		"version":        serverVersion,
+		"build_commit":   buildCommit,
+		"uptime_seconds": int(uptime.Seconds()),
+		"started_at":     startTime.UTC().Format(time.RFC3339),
+		"catalog_size":   len(catalog.List()),
+		"transport":      serverTransport,
+		"capabilities": map[string]interface{}{
+			"tools":       true,
+			"prompts":     true,
+			"admin_tools": adminEnabled,
+		},
+		"store_open": storeOpen(time.Now()),
+		"locale":     defaultLocale,
+		"message":    fmt.Sprintf("%s %s (commit %s), up %v", serverName, serverVersion, buildCommit, uptime.Round(time.Second)),
+	}), nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Supported locales for tool result messages
+const (
+	localeEN   = "en"
+	localeZhTW = "zh-TW"
+)
+
+// defaultLocale is the language of tool messages when a call does not ask
+// for one
+var defaultLocale = localeEN
+
+// messageCatalog holds the message templates per locale. English is complete;
+// other locales fall back to English for keys they do not define.
+var messageCatalog = map[string]map[string]string{
+	localeEN: {
+		"price":                   "The price of %s is %.2f",
+		"price_preorder":          "The price of %s is %.2f, available for preorder, ships on %s",
+		"price_lookup_missing":    "Give a product_id, or a product name or alias as lookup",
+		"price_lookup_not_found":  "No product has the ID, name or alias %s",
+		"product_suggestions":     "Product %s not found, did you mean %s (ID %s)?",
+		"product_details":         "Details of %s (ID %s) with %d attribute(s)",
+		"attribute_compared":      "%s: %s has %s, %s has %s",
+		"attribute_missing_one":   "%s: %s has %s, %s does not list it",
+		"attribute_missing_both":  "Neither %s nor %s lists %s",
+		"cheapest_product":        "The cheapest product is %s (ID %s) at %.2f",
+		"most_expensive_product":  "The most expensive product is %s (ID %s) at %.2f",
+		"no_products":             "The catalog has no products",
+		"no_products_in_category": "No products in category %s",
+		"product_page":            "Products %d-%d of %d",
+		"product_page_empty":      "No products at offset %d, the catalog has %d",
+		"invalid_limit":           "limit must be an integer from 1 to %d",
+		"invalid_offset":          "offset must be an integer of 0 or more",
+		"unknown_sort_key":        "Unknown sort_by %s, use one of %s",
+		"bill_split_equal":        "%.2f split between %d people is %.2f each",
+		"bill_split":              "%.2f split between %d people comes to %.2f to %.2f each",
+		"split_share_count":       "people is %d but %d shares were given",
+		"alias_resolved":          "%s means %s (ID %s)",
+		"alias_not_found":         "No product is known as %s",
+		"total_price":             "Total price is %.2f",
+		"total_price_preorder":    "Total price is %.2f, includes preorder items that ship on %s",
+		"preorder_not_allowed":    "%s is not released until %s, pass allow_preorder to preorder it",
+		"quantity_missing":        "No quantity given for %s",
+		"item_invalid_format":     "Item %d is not an object with product_id and quantity",
+		"item_product_id_invalid": "Item %d has no product_id string",
+		"product_not_found":       "Product with ID %s not found",
+		"quantity_invalid_format": "The quantity of %s must be a number or a numeral string",
+		"quantity_not_integer":    "The quantity of %s must be a whole number, got %v",
+		"quantity_not_positive":   "The quantity of %s must be greater than 0",
+		"quantity_parsed":         "%s means %d",
+		"line_quantity_limit":     "%d %s requested, but at most %d can be on one line",
+		"items_invalid":           "%d problem(s) found in items: %s",
+		"order_quantity_limit":    "Total quantity %d exceeds the maximum of %d per order",
+		"line_items_limit":        "%d line items exceed the maximum of %d per request, combine repeated products or split the request",
+		"product_quantity_limit":  "%d %s requested, but at most %d can be ordered at once",
+		"discount_range":          "discount_percentage must be between 0 and 100",
+		"tax_rate_range":          "tax_rate must be at least 0 and less than 1, e.g. 0.05 for 5%%",
+		"total_token_invalid":     "The total_token was not issued by calculate_total, call calculate_total again",
+		"total_token_expired":     "The total_token has expired, call calculate_total again",
+		"total_token_mismatch":    "total_price %.2f does not match the calculated total %.2f",
+		"discount_applied":        "Original price: %.2f, paying %.0f%% (%.0f%% off): %.2f (You save: %.2f)",
+		"discounts_applied":       "Original price: %.2f, after %d discount(s): %.2f (You save: %.2f)",
+		"gift_wrap":               "Base price: %.2f, gift wrapping: %.2f, new total: %.2f",
+		"price_updated":           "The price of %s changed from %.2f to %.2f",
+		"product_added":           "Added %s (ID %s) at %.2f",
+		"product_removed":         "Removed %s (ID %s) from the catalog",
+		"catalog_imported":        "Imported %d products, replacing %d",
+		"catalog_invalid":         "Catalog not imported, %d problem(s) found",
+		"catalog_not_array":       "Catalog not imported, products must be an array of product objects",
+		"invalid_time":            "%s must be an RFC3339 timestamp such as 2024-01-02T15:04:05Z",
+		"audit_entries":           "%d audit log entries",
+		"points_earned":           "Earned %d points, balance is now %d points (worth %.2f)",
+		"points_insufficient":     "Only %d points available, %d requested",
+		"points_redeemed":         "Redeemed %d points for %.2f off, new total: %.2f, remaining balance: %d points",
+		"wishlist_added":          "Saved %s to the wishlist, %d item(s) saved",
+		"wishlist_already_saved":  "%s is already on the wishlist, %d item(s) saved",
+		"wishlist_items":          "%d item(s) on the wishlist",
+		"wishlist_price_drops":    "%d item(s) on the wishlist, %d now cheaper than when saved",
+		"tool_stats":              "%d tool calls recorded across %d tools, %d errors",
+		"order_duplicate":         "Order %s was already placed with this idempotency key, total price is %.2f",
+		"insufficient_stock":      "Only %[1]d %[2]s left in stock, %[3]d requested",
+		"order_preview":           "Order preview: total price would be %.2f, nothing has been placed",
+		"order_placed":            "Order %s placed, total price is %.2f",
+		"order_cancelled":         "Order %s cancelled, %.2f refunded and the items returned to stock",
+		"order_not_found":         "Order %s not found",
+		"order_already_cancelled": "Order %s is already cancelled",
+		"receipt_ready":           "Receipt for order %s, total %.2f",
+		"receipt_title":           "Receipt for order %s",
+		"receipt_placed":          "Placed: %s",
+		"receipt_cancelled":       "Cancelled: %s, %.2f refunded",
+		"receipt_line":            "%d x %s @ %.2f = %.2f",
+		"receipt_subtotal":        "Subtotal: %.2f",
+		"receipt_tax":             "Tax (%s%%): %.2f",
+		"receipt_tax_included":    "Tax included (%s%%): %.2f",
+		"receipt_total":           "Total: %.2f",
+		"product_image":           "Image of %s",
+		"product_image_missing":   "%s has no image",
+		"order_below_minimum":     "Subtotal %.2f is below the minimum order value of %.2f, add %.2f more",
+		"order_valid":             "The order is valid, total price would be %.2f",
+		"pong":                    "pong at %s",
+		"price_history_changed":   "The price of %s has changed %d time(s), currently %.2f",
+		"price_history_unchanged": "The price of %s has not changed, currently %.2f",
+		"shipping_cost":           "Shipping for %.2f kg (%s) to %s costs %.2f",
+		"shipping_unknown_zone":   "Unknown destination %s, ship to one of %s",
+		"shipping_free":           "Free shipping on orders of %.2f or more",
+		"invalid_arguments":       "Invalid arguments for %s: %s",
+		"tool_not_found":          "No tool named %s",
+		"tool_schema":             "Input schema of %s",
+		"rate_limited":            "Too many %s calls, retry after %.1f seconds",
+		"server_busy":             "The server is busy running %d calls, retry shortly",
+		"internal_error":          "%s failed because of an internal error, the server logged the details",
+		"store_closed":            "The store is closed. Business hours are %s (%s); it opens again at %s",
+		"result_too_large":        "The result of %s is %d bytes, over the %d byte limit, and was not returned",
+	},
+	localeZhTW: {
+		"price":                   "%s 的價格是 %.2f",
+		"price_preorder":          "%s 的價格是 %.2f，目前開放預購，%s 出貨",
+		"price_lookup_missing":    "請提供 product_id，或以 lookup 提供商品名稱或別名",
+		"price_lookup_not_found":  "沒有 ID、名稱或別名為 %s 的商品",
+		"product_suggestions":     "找不到商品 %s，您是指 %s（ID %s）嗎？",
+		"product_details":         "%s（ID %s）的詳細資料，共 %d 項規格",
+		"attribute_compared":      "%s：%s 為 %s，%s 為 %s",
+		"attribute_missing_one":   "%s：%s 為 %s，%s 沒有這項資料",
+		"attribute_missing_both":  "%s 和 %s 都沒有 %s 的資料",
+		"cheapest_product":        "最便宜的商品是 %s（ID %s），價格 %.2f",
+		"most_expensive_product":  "最貴的商品是 %s（ID %s），價格 %.2f",
+		"no_products":             "商品目錄中沒有任何商品",
+		"no_products_in_category": "分類 %s 中沒有任何商品",
+		"product_page":            "第 %d 到 %d 項商品，共 %d 項",
+		"product_page_empty":      "從第 %d 項之後沒有商品，目錄共有 %d 項",
+		"invalid_limit":           "limit 必須是 1 到 %d 的整數",
+		"invalid_offset":          "offset 必須是 0 以上的整數",
+		"unknown_sort_key":        "不支援的排序方式 %s，請使用 %s",
+		"bill_split_equal":        "%.2f 由 %d 人平分，每人 %.2f",
+		"bill_split":              "%.2f 由 %d 人分攤，每人 %.2f 到 %.2f 不等",
+		"split_share_count":       "people 為 %d，但 shares 有 %d 項",
+		"alias_resolved":          "%s 指的是 %s（ID %s）",
+		"alias_not_found":         "找不到名為 %s 的商品",
+		"total_price":             "總價是 %.2f",
+		"total_price_preorder":    "總價是 %.2f，含預購商品，%s 出貨",
+		"preorder_not_allowed":    "%s 要到 %s 才發售，需設定 allow_preorder 才能預購",
+		"quantity_missing":        "未指定 %s 的數量",
+		"item_invalid_format":     "第 %d 項不是包含 product_id 與 quantity 的物件",
+		"item_product_id_invalid": "第 %d 項缺少字串格式的 product_id",
+		"product_not_found":       "找不到 ID 為 %s 的商品",
+		"quantity_invalid_format": "%s 的數量必須是數字或數字字串",
+		"quantity_not_integer":    "%s 的數量必須是整數，收到 %v",
+		"quantity_not_positive":   "%s 的數量必須大於 0",
+		"quantity_parsed":         "%s 是 %d",
+		"line_quantity_limit":     "%[2]s 要求 %[1]d 件，但單一品項最多 %[3]d 件",
+		"items_invalid":           "品項中有 %d 個問題：%s",
+		"order_quantity_limit":    "總數量 %d 超過每筆訂單上限 %d",
+		"line_items_limit":        "品項數 %d 超過每次請求上限 %d，請合併相同商品或分批查詢",
+		"product_quantity_limit":  "要求 %[1]d 件%[2]s，但每筆訂單最多只能訂 %[3]d 件",
+		"discount_range":          "discount_percentage 必須介於 0 到 100 之間",
+		"tax_rate_range":          "tax_rate 必須大於等於 0 且小於 1，例如 0.05 代表 5%%",
+		"total_token_invalid":     "total_token 不是由 calculate_total 產生，請重新呼叫 calculate_total",
+		"total_token_expired":     "total_token 已過期，請重新呼叫 calculate_total",
+		"total_token_mismatch":    "total_price %.2f 與計算出的總價 %.2f 不符",
+		"discount_applied":        "原價 %.2f，支付 %.0f%%（折抵 %.0f%%）：%.2f（省下 %.2f）",
+		"discounts_applied":       "原價 %.2f，套用 %d 個折扣後：%.2f（省下 %.2f）",
+		"gift_wrap":               "原價 %.2f，禮品包裝 %.2f，新總價 %.2f",
+		"price_updated":           "%s 的價格已從 %.2f 調整為 %.2f",
+		"product_added":           "已新增 %s（ID %s），價格 %.2f",
+		"product_removed":         "已從目錄移除 %s（ID %s）",
+		"catalog_imported":        "已匯入 %d 項商品，取代原有的 %d 項",
+		"catalog_invalid":         "商品目錄未匯入，發現 %d 個問題",
+		"catalog_not_array":       "商品目錄未匯入，products 必須是商品物件的陣列",
+		"invalid_time":            "%s 必須是 RFC3339 時間格式，例如 2024-01-02T15:04:05Z",
+		"audit_entries":           "共 %d 筆稽核紀錄",
+		"points_earned":           "獲得 %d 點，目前共 %d 點（價值 %.2f）",
+		"points_insufficient":     "只有 %d 點可用，要求折抵 %d 點",
+		"points_redeemed":         "使用 %d 點折抵 %.2f，新總價 %.2f，剩餘 %d 點",
+		"wishlist_added":          "已將 %s 加入願望清單，共 %d 項",
+		"wishlist_already_saved":  "%s 已在願望清單中，共 %d 項",
+		"wishlist_items":          "願望清單共有 %d 項",
+		"wishlist_price_drops":    "願望清單共有 %d 項，其中 %d 項比收藏時便宜",
+		"tool_stats":              "共記錄 %d 次工具呼叫，涵蓋 %d 個工具，%d 次錯誤",
+		"order_duplicate":         "這個冪等鍵已建立過訂單 %s，總價 %.2f",
+		"insufficient_stock":      "%[2]s 庫存只剩 %[1]d 件，要求 %[3]d 件",
+		"order_preview":           "訂單預覽：總價為 %.2f，尚未下單",
+		"order_placed":            "訂單 %s 已成立，總價 %.2f",
+		"order_cancelled":         "訂單 %s 已取消，退款 %.2f，商品已放回庫存",
+		"order_not_found":         "找不到訂單 %s",
+		"order_already_cancelled": "訂單 %s 已經取消過了",
+		"receipt_ready":           "訂單 %s 的收據，總計 %.2f",
+		"receipt_title":           "訂單 %s 收據",
+		"receipt_placed":          "下單時間：%s",
+		"receipt_cancelled":       "已於 %s 取消，退款 %.2f",
+		"receipt_line":            "%d x %s @ %.2f = %.2f",
+		"receipt_subtotal":        "小計：%.2f",
+		"receipt_tax":             "稅額（%s%%）：%.2f",
+		"receipt_tax_included":    "內含稅額（%s%%）：%.2f",
+		"receipt_total":           "總計：%.2f",
+		"product_image":           "%s 的商品圖片",
+		"product_image_missing":   "%s 沒有商品圖片",
+		"order_below_minimum":     "小計 %.2f 未達最低訂購金額 %.2f，還差 %.2f",
+		"order_valid":             "訂單可以成立，總價為 %.2f",
+		"pong":                    "pong，伺服器時間 %s",
+		"price_history_changed":   "%s 的價格共調整過 %d 次，目前為 %.2f",
+		"price_history_unchanged": "%s 的價格未曾調整，目前為 %.2f",
+		"shipping_cost":           "%.2f 公斤（%s）寄到 %s 的運費為 %.2f",
+		"shipping_unknown_zone":   "不支援的寄送地區 %s，可寄送至 %s",
+		"shipping_free":           "訂單滿 %.2f 免運費",
+		"invalid_arguments":       "%s 的參數不正確：%s",
+		"tool_not_found":          "找不到名為 %s 的工具",
+		"tool_schema":             "%s 的輸入結構描述",
+		"rate_limited":            "%s 呼叫太頻繁，請在 %.1f 秒後重試",
+		"server_busy":             "伺服器正在處理 %d 個呼叫，請稍後再試",
+		"internal_error":          "%s 發生內部錯誤，詳細資訊已記錄在伺服器 log",
+		"store_closed":            "目前不在營業時間，營業時間為 %s（%s），將於 %s 開始營業",
+		"result_too_large":        "%s 的結果有 %d 位元組，超過 %d 位元組的上限，因此未回傳",
+	},
+}
+
+// normalizeLocale maps a requested locale to a supported one, or returns ""
+// when it is not supported
+func normalizeLocale(locale string) string {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")) {
+	case "en", "en-us", "en-gb":
+		return localeEN
+	case "zh-tw", "zh-hant", "zh-hant-tw":
+		return localeZhTW
+	}
+	return ""
+}
+
+type localeKey struct{}
+
+// localeFromContext returns the locale of the current tool call
+func localeFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok {
+		return locale
+	}
+	return defaultLocale
+}
+
+// localize formats the message with the given key in the call's locale,
+// falling back to English. Arguments of type money are written with the
+// locale's number format.
+func localize(ctx context.Context, key string, args ...interface{}) string {
+	locale := localeFromContext(ctx)
+	template, ok := messageCatalog[locale][key]
+	if !ok {
+		template = messageCatalog[localeEN][key]
+	}
+	// Bind money to the format in a copy, since args may be the caller's slice
+	formatted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if amount, ok := arg.(money); ok {
+			arg = formattedMoney{amount: amount, format: numberFormatFor(locale)}
+		}
+		formatted[i] = arg
+	}
+	return fmt.Sprintf(template, formatted...)
+}
+
+// requestLocale picks the locale of a call from _meta.locale or a locale
+// argument, falling back to the server default for missing or unsupported
+// values
+func requestLocale(req mcp.CallToolRequest) string {
+	var requested string
+	if req.Params.Meta != nil {
+		requested, _ = req.Params.Meta.AdditionalFields["locale"].(string)
+	}
+	if requested == "" {
+		requested, _ = req.GetArguments()["locale"].(string)
+	}
+
+	if locale := normalizeLocale(requested); locale != "" {
+		return locale
+	}
+	return defaultLocale
+}
+
+// localeMiddleware stores the locale of a call in its context for localize
+func localeMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return next(context.WithValue(ctx, localeKey{}, requestLocale(req)), req)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLocalizeCurrencySymbol(t *testing.T) {
+	tests := []struct {
+		currency, override string
+		want               string
+	}{
+		{"USD", "", "Total price is $1,000.00"},
+		{"TWD", "", "Total price is NT$1,000.00"},
+		{"JPY", "", "Total price is ¥1,000"},
+		{"EUR", "", "Total price is €1,000.00"},
+		{"KWD", "", "Total price is KD 1,000.000"},
+		{"USD", "US$", "Total price is US$1,000.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency+tt.override, func(t *testing.T) {
+			setVar(t, &storeCurrency, tt.currency)
+			setVar(t, &currencySymbolOverride, tt.override)
+			if got := localize(context.Background(), "total_price", money(1000)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizeKeepsCallerArgs(t *testing.T) {
+	args := []interface{}{"Laptop", money(1000)}
+	localize(context.Background(), "price_history_unchanged", args...)
+	if _, ok := args[1].(money); !ok {
+		t.Errorf("localize replaced the caller's argument with %T", args[1])
+	}
+}
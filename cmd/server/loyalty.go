@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var (
+	// pointsPerDollar is how many loyalty points each dollar spent earns
+	pointsPerDollar = 1.0
+	// pointValue is the dollar value of a single point when redeemed
+	pointValue = 0.01
+)
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "subtotal": {"type": "number"},
+	    "session_id": {"type": "string"}
+	  },
+	  "required": ["subtotal"]
+	}
+*/
+func earnPointsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	subtotal, ok := args["subtotal"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing subtotal")
+	}
+	if subtotal < 0 {
+		return jsonError(map[string]interface{}{
+			"success":  false,
+			"error":    "subtotal cannot be negative",
+			"subtotal": subtotal,
+		}), nil
+	}
+
+	id := sessionID(ctx, args)
+	earned := int(math.Floor(subtotal * pointsPerDollar))
+	var balance int
+	sessions.with(id, func(state *sessionState) {
+		state.Points += earned
+		balance = state.Points
+	})
+
+	return jsonResult(map[string]interface{}{
+		"success":       true,
+		"session_id":    id,
+		"subtotal":      roundMoney(subtotal),
+		"points_earned": earned,
+		"balance":       balance,
+		"balance_value": roundMoney(float64(balance) * pointValue),
+		"message":       localize(ctx, "points_earned", earned, balance, money(float64(balance)*pointValue)),
+	}), nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "points": {"type": "number"},
+	    "total_price": {"type": "number"},
+	    "session_id": {"type": "string"}
+	  },
+	  "required": ["points", "total_price"]
+	}
+*/
+func redeemPointsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	pointsArg, ok := args["points"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing points")
+	}
+	totalPrice, ok := args["total_price"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing total_price")
+	}
+	if pointsArg != float64(int(pointsArg)) || pointsArg <= 0 {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "points must be a positive integer",
+			"points":  pointsArg,
+		}), nil
+	}
+
+	id := sessionID(ctx, args)
+	points := int(pointsArg)
+	totalPrice = roundMoney(totalPrice)
+
+	// Only redeem as many points as are needed to cover the total
+	if maxUseful := int(math.Ceil(totalPrice / pointValue)); points > maxUseful {
+		points = maxUseful
+	}
+
+	var balance int
+	insufficient := false
+	sessions.with(id, func(state *sessionState) {
+		if points > state.Points {
+			insufficient = true
+		} else {
+			state.Points -= points
+		}
+		balance = state.Points
+	})
+	if insufficient {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "Insufficient points",
+			"session_id": id,
+			"requested":  points,
+			"balance":    balance,
+			"message":    localize(ctx, "points_insufficient", balance, points),
+		}), nil
+	}
+
+	discount := math.Min(roundMoney(float64(points)*pointValue), totalPrice)
+	newTotal := roundMoney(totalPrice - discount)
+
+	return jsonResult(map[string]interface{}{
+		"success":         true,
+		"tax_inclusive":   pricesIncludeTax,
+		"session_id":      id,
+		"points_redeemed": points,
+		"points_value":    discount,
+		"original_price":  totalPrice,
+		"total_price":     newTotal,
+		"balance":         balance,
+		"balance_value":   roundMoney(float64(balance) * pointValue),
+		"message":         localize(ctx, "points_redeemed", points, money(discount), money(newTotal), balance),
+	}), nil
+}
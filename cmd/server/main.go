@@ -3,10 +3,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -14,25 +19,118 @@ import (
 
 // Product represents a product in the store
 type Product struct {
-	ID    string  `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Category string  `json:"category,omitempty"`
+	Stock    int     `json:"stock"`
+	Weight   float64 `json:"weight"` // shipping weight in kilograms
+	// MaxPerOrder caps the quantity of this product in one order; 0 uses the
+	// global maxLineQuantity
+	MaxPerOrder int `json:"max_per_order,omitempty"`
+	// AvailableFrom is the release date of a product that can only be
+	// preordered until then; zero means it is available now
+	AvailableFrom time.Time `json:"available_from,omitzero"`
+	// Attributes holds free-form specs such as "ram": "16GB" or
+	// "color": "silver"
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// ImageURL is an absolute http(s) URL of a picture of the product for
+	// clients that show thumbnails; empty means it has none
+	ImageURL string `json:"image_url,omitempty"`
 }
 
 // Default products available in the store
 var defaultProducts = []Product{
-	{ID: "1", Name: "Laptop", Price: 1000.0},
-	{ID: "2", Name: "Smartphone", Price: 500.0},
-	{ID: "3", Name: "Tablet", Price: 300.0},
+	{ID: "1", Name: "Laptop", Price: 1000.0, Category: "computers", Stock: 10, Weight: 2.0, MaxPerOrder: 5,
+		Attributes: map[string]string{"ram": "16GB", "storage": "512GB", "screen": "14 inch", "color": "silver"}},
+	{ID: "2", Name: "Smartphone", Price: 500.0, Category: "mobile", Stock: 50, Weight: 0.2, MaxPerOrder: 30,
+		Attributes: map[string]string{"ram": "8GB", "storage": "128GB", "screen": "6.1 inch", "color": "black"}},
+	{ID: "3", Name: "Tablet", Price: 300.0, Category: "mobile", Stock: 30, Weight: 0.5,
+		Attributes: map[string]string{"storage": "64GB", "screen": "10.9 inch", "color": "space gray"}},
+	// Released a month after the server starts so the demo always has a
+	// preorder product
+	{ID: "4", Name: "Smartwatch", Price: 250.0, Category: "wearables", Stock: 40, Weight: 0.1,
+		AvailableFrom: time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 30),
+		Attributes:    map[string]string{"screen": "1.9 inch", "color": "black", "battery": "18 hours"}},
+}
+
+// catalog holds the live product data, including stock levels
+var catalog = NewCatalog(defaultProducts)
+
+var (
+	// maxLineQuantity is the largest quantity allowed for a single line item
+	maxLineQuantity = 1000
+	// maxOrderQuantity is the largest quantity allowed across all line items
+	maxOrderQuantity = 5000
+	// maxLineItems is the largest number of line items one request may hold
+	maxLineItems = 100
+	// minOrderValue is the smallest subtotal place_order accepts; 0 means no minimum
+	minOrderValue = 0.0
+	// defaultMissingQuantity treats an item without a quantity as 1 instead
+	// of rejecting it. Only tools whose schema leaves quantity optional, such
+	// as calculate_total, ever see such items.
+	defaultMissingQuantity = true
+)
+
+// maxPerOrder returns the largest quantity of the product one order may hold
+func (p Product) maxPerOrder() int {
+	if p.MaxPerOrder > 0 {
+		return p.MaxPerOrder
+	}
+	return maxLineQuantity
+}
+
+// lineItem is a validated product and quantity pair from an items argument
+type lineItem struct {
+	Product  Product
+	Quantity int
+	// Defaulted is set when the item had no quantity and 1 was assumed
+	Defaulted bool
+}
+
+// structuredResultURI identifies the embedded JSON resource that carries the
+// structured data of a tool result
+const structuredResultURI = "store://tool-result"
+
+// resultSchemaVersion is the version of the structured result format. Bump it
+// when existing fields change meaning or are removed; adding fields does not
+// need a new version.
+const resultSchemaVersion = 1
+
+// jsonResult wraps structured data as a successful tool result. The data is
+// returned twice: as JSON text for clients that only read text content, and
+// as an embedded application/json resource that clients can recognize by its
+// MIME type instead of guessing whether the text is JSON. Every result carries
+// the schema_version of its format.
+func jsonResult(data map[string]interface{}) *mcp.CallToolResult {
+	data["schema_version"] = resultSchemaVersion
+	resultJSON, _ := json.Marshal(data)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(string(resultJSON)),
+			mcp.NewEmbeddedResource(mcp.TextResourceContents{
+				URI:      structuredResultURI,
+				MIMEType: "application/json",
+				Text:     string(resultJSON),
+			}),
+		},
+	}
+}
+
+// jsonError wraps structured data as an error tool result
+func jsonError(data map[string]interface{}) *mcp.CallToolResult {
+	result := jsonResult(data)
+	result.IsError = true
+	return result
 }
 
 /*
 	{
 	  "type": "object",
 	  "properties": {
-	    "product_id": {"type": "string"}
-	  },
-	  "required": ["product_id"]
+	    "product_id": {"type": "string"},
+	    "lookup": {"type": "string"}
+	  }
 	}
 */
 func getPriceHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -41,39 +139,77 @@ func getPriceHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToo
 		return nil, fmt.Errorf("no arguments provided")
 	}
 
-	productID, ok := args["product_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("product_id is not a string")
-	}
-
-	for _, product := range defaultProducts {
-		if product.ID == productID {
-			// Return structured data
-			result := map[string]interface{}{
-				"success":      true,
-				"product_id":   product.ID,
-				"product_name": product.Name,
-				"price":        product.Price,
-				"message":      fmt.Sprintf("The price of %s is $%.2f", product.Name, product.Price),
-			}
-			resultJSON, _ := json.Marshal(result)
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{mcp.NewTextContent(string(resultJSON))},
-			}, nil
+	// product_id keeps its exact ID behavior; lookup also accepts a product
+	// name or alias and is only used without a product_id
+	productID, hasID := args["product_id"].(string)
+	lookup, hasLookup := args["lookup"].(string)
+	if !hasID && !hasLookup {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "Give product_id or lookup",
+			"message": localize(ctx, "price_lookup_missing"),
+		}), nil
+	}
+
+	if hasID {
+		if product, ok := catalog.Get(productID); ok {
+			result := priceResult(ctx, product)
+			result["matched_on"] = "id"
+			return jsonResult(result), nil
 		}
+	} else if product, matchedOn, ok := resolveAlias(lookup); ok {
+		result := priceResult(ctx, product)
+		result["lookup"] = lookup
+		result["matched_on"] = matchedOn
+		return jsonResult(result), nil
 	}
 
-	// Return structured error
+	// Return structured error, with close matches so the caller can retry
 	errorResult := map[string]interface{}{
-		"success":    false,
-		"error":      "Product not found",
-		"product_id": productID,
+		"success": false,
+		"error":   "Product not found",
 	}
-	errorJSON, _ := json.Marshal(errorResult)
-	return &mcp.CallToolResult{
-		IsError: true,
-		Content: []mcp.Content{mcp.NewTextContent(string(errorJSON))},
-	}, nil
+	query := productID
+	if hasID {
+		errorResult["product_id"] = productID
+	} else {
+		query = lookup
+		errorResult["lookup"] = lookup
+		errorResult["message"] = localize(ctx, "price_lookup_not_found", lookup)
+	}
+	if suggestions := suggestProducts(query); len(suggestions) > 0 {
+		errorResult["suggestions"] = suggestions
+		errorResult["message"] = localize(ctx, "product_suggestions", query, suggestions[0].ProductName, suggestions[0].ProductID)
+	}
+	return jsonError(errorResult), nil
+}
+
+// priceResult builds the get_price result for a product, with its
+// availability and tax breakdown
+func priceResult(ctx context.Context, product Product) map[string]interface{} {
+	available := product.available(time.Now())
+	result := map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"currency":      storeCurrency,
+		"product_id":    product.ID,
+		"product_name":  product.Name,
+		"price":         roundMoney(product.Price),
+		"max_per_order": product.maxPerOrder(),
+		"available":     available,
+		"message":       localize(ctx, "price", product.Name, money(product.Price)),
+	}
+	if !available {
+		result["preorder"] = true
+		result["available_from"] = product.releaseDate()
+		result["message"] = localize(ctx, "price_preorder", product.Name, money(product.Price), product.releaseDate())
+	}
+	net, tax, gross := taxBreakdown(product.Price, taxRate)
+	result["tax_rate"] = taxRate
+	result["net_price"] = net
+	result["tax"] = tax
+	result["gross_price"] = gross
+	return result
 }
 
 /*
@@ -86,11 +222,14 @@ func getPriceHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToo
 	        "type": "object",
 	        "properties": {
 	          "product_id": {"type": "string"},
-	          "quantity": {"type": "integer"}
+	          "quantity": {"type": ["integer", "string"]}
 	        },
-	        "required": ["product_id", "quantity"]
+	        "required": ["product_id"]
 	      }
-	    }
+	    },
+	    "tax_rate": {"type": "number"},
+	    "per_line_tax": {"type": "boolean"},
+	    "allow_preorder": {"type": "boolean"}
 	  },
 	  "required": ["items"]
 	}
@@ -109,111 +248,244 @@ func calculateTotalHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.C
 		return nil, fmt.Errorf("items is not an array")
 	}
 
-	// Validate product quantity
-	for _, itemInterface := range items {
+	lines, errResult := parseItems(ctx, items)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	// tax_rate overrides the store's rate for this call only
+	rate := taxRate
+	if v, ok := args["tax_rate"].(float64); ok {
+		if v < 0 || v >= 1 {
+			return jsonError(map[string]interface{}{
+				"success":  false,
+				"error":    "Invalid tax rate",
+				"tax_rate": v,
+				"message":  localize(ctx, "tax_rate_range"),
+			}), nil
+		}
+		rate = v
+	}
+	perLineTax, _ := args["per_line_tax"].(bool)
+	allowPreorder, _ := args["allow_preorder"].(bool)
+
+	total := 0.0
+	itemDetails := make([]map[string]interface{}, 0, len(lines))
+	itemTotals := make([]float64, 0, len(lines))
+
+	for _, line := range lines {
+		itemTotal := roundMoney(line.Product.Price * float64(line.Quantity))
+		total = roundMoney(total + itemTotal)
+		itemTotals = append(itemTotals, itemTotal)
+
+		// Add item details
+		itemDetails = append(itemDetails, map[string]interface{}{
+			"product_id":         line.Product.ID,
+			"product_name":       line.Product.Name,
+			"price":              roundMoney(line.Product.Price),
+			"quantity":           line.Quantity,
+			"defaulted_quantity": line.Defaulted,
+			"item_total":         itemTotal,
+		})
+	}
+
+	// Unreleased products are only priced when the caller accepts a preorder
+	shipsOn, preorderErr := checkPreorders(ctx, lines, allowPreorder, func(i int, date string) {
+		itemDetails[i]["preorder"] = true
+		itemDetails[i]["available_from"] = date
+	})
+	if preorderErr != nil {
+		return jsonError(preorderErr), nil
+	}
+
+	// Return structured data
+	result := map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"currency":      storeCurrency,
+		"total_price":   total,
+		"items":         itemDetails,
+		"item_count":    len(itemDetails),
+		"message":       localize(ctx, "total_price", money(total)),
+	}
+	if shipsOn != "" {
+		result["preorder"] = true
+		result["ships_on"] = shipsOn
+		result["message"] = localize(ctx, "total_price_preorder", money(total), shipsOn)
+	}
+	net, tax, gross := taxBreakdown(total, rate)
+	result["tax_rate"] = rate
+	result["net_total"] = net
+	result["tax"] = tax
+	result["gross_total"] = gross
+	result["total_token"] = issueTotalToken(total)
+
+	// Split the order tax across the lines so the line taxes add up to it
+	if perLineTax {
+		for i, lineTax := range allocateTax(tax, itemTotals) {
+			itemDetails[i]["tax"] = lineTax
+		}
+	}
+	return jsonResult(result), nil
+}
+
+// parseItems validates an items argument and resolves each entry against the
+// catalog. Every problem is collected rather than stopping at the first, so
+// the caller can fix all of them at once. On failure it returns the error
+// result to send back to the caller.
+func parseItems(ctx context.Context, items []interface{}) ([]lineItem, *mcp.CallToolResult) {
+	// Reject oversized requests before looking up any product
+	if len(items) > maxLineItems {
+		return nil, jsonError(map[string]interface{}{
+			"success":        false,
+			"error":          "Too many line items",
+			"item_count":     len(items),
+			"max_line_items": maxLineItems,
+			"message":        localize(ctx, "line_items_limit", len(items), maxLineItems),
+		})
+	}
+
+	lines := make([]lineItem, 0, len(items))
+	totalQuantity := 0
+	var problems []map[string]interface{}
+
+	// problem records what is wrong with the item at index
+	problem := func(index int, productID, errorText, message string) map[string]interface{} {
+		p := map[string]interface{}{
+			"index":   index,
+			"error":   errorText,
+			"message": message,
+		}
+		if productID != "" {
+			p["product_id"] = productID
+		}
+		problems = append(problems, p)
+		return p
+	}
+
+	for i, itemInterface := range items {
 		item, ok := itemInterface.(map[string]interface{})
 		if !ok {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{mcp.NewTextContent("Invalid item format")},
-			}, nil
+			problem(i, "", "Invalid item format", localize(ctx, "item_invalid_format", i))
+			continue
 		}
 
 		// Validate product ID
 		productID, ok := item["product_id"].(string)
 		if !ok {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{mcp.NewTextContent("Invalid product ID format")},
-			}, nil
+			problem(i, "", "Invalid product ID format", localize(ctx, "item_product_id_invalid", i))
+			continue
 		}
 
-		// Validate product existence
-		productExists := false
-		for _, p := range defaultProducts {
-			if p.ID == productID {
-				productExists = true
-				break
+		// Validate product existence; the quantity is still checked so that
+		// its problems are reported too
+		product, found := catalog.Get(productID)
+		if !found {
+			p := problem(i, productID, "Product not found", localize(ctx, "product_not_found", productID))
+			if suggestions := suggestProducts(productID); len(suggestions) > 0 {
+				p["suggestions"] = suggestions
+				p["message"] = localize(ctx, "product_suggestions", productID, suggestions[0].ProductName, suggestions[0].ProductID)
 			}
 		}
-		if !productExists {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{mcp.NewTextContent(fmt.Sprintf("Product with ID %s not found", productID))},
-			}, nil
-		}
-
-		// Validate quantity
-		quantity, ok := item["quantity"].(float64)
-		if !ok {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{mcp.NewTextContent("Invalid quantity format")},
-			}, nil
+		name := product.Name
+		if !found {
+			name = productID
 		}
 
-		// Check if quantity is an integer
-		if quantity != float64(int(quantity)) {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{mcp.NewTextContent("Quantity must be an integer")},
-			}, nil
+		// Validate quantity, normalizing strings such as "三十" or "a dozen"
+		var quantity float64
+		defaulted := false
+		switch q := item["quantity"].(type) {
+		case nil:
+			if !defaultMissingQuantity {
+				problem(i, productID, "Missing quantity", localize(ctx, "quantity_missing", name))
+				continue
+			}
+			quantity, defaulted = 1, true
+		case float64:
+			quantity = q
+		case string:
+			n, err := parseQuantity(q)
+			if err != nil {
+				problem(i, productID, "Unrecognized quantity", err.Error())["input"] = q
+				continue
+			}
+			quantity = float64(n)
+		default:
+			problem(i, productID, "Invalid quantity format", localize(ctx, "quantity_invalid_format", name))
+			continue
 		}
 
-		// Check if quantity is positive
-		if quantity <= 0 {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{mcp.NewTextContent("Quantity must be greater than 0")},
-			}, nil
+		switch {
+		case quantity != float64(int(quantity)):
+			problem(i, productID, "Quantity must be an integer", localize(ctx, "quantity_not_integer", name, quantity))
+			continue
+		case quantity <= 0:
+			problem(i, productID, "Quantity must be greater than 0", localize(ctx, "quantity_not_positive", name))
+			continue
+		case found && product.MaxPerOrder == 0 && quantity > float64(maxLineQuantity):
+			// Products with their own cap are checked across all lines below
+			p := problem(i, productID, fmt.Sprintf("Quantity cannot exceed %d", maxLineQuantity), localize(ctx, "line_quantity_limit", int(quantity), name, maxLineQuantity))
+			p["requested"] = int(quantity)
+			p["max_quantity"] = maxLineQuantity
+			continue
 		}
 
-		// Check if quantity is within reasonable range
-		if quantity > 1000 {
-			return &mcp.CallToolResult{
-				IsError: true,
-				Content: []mcp.Content{mcp.NewTextContent("Quantity cannot exceed 1000")},
-			}, nil
+		if found {
+			lines = append(lines, lineItem{Product: product, Quantity: int(quantity), Defaulted: defaulted})
+			totalQuantity += int(quantity)
 		}
 	}
 
-	total := 0.0
-	var itemDetails []map[string]interface{}
-
-	for _, itemInterface := range items {
-		item := itemInterface.(map[string]interface{})
-		productID := item["product_id"].(string)
-		quantity := int(item["quantity"].(float64))
-		for _, p := range defaultProducts {
-			if p.ID == productID {
-				itemTotal := p.Price * float64(quantity)
-				total += itemTotal
-
-				// Add item details
-				itemDetails = append(itemDetails, map[string]interface{}{
-					"product_id":   productID,
-					"product_name": p.Name,
-					"price":        p.Price,
-					"quantity":     quantity,
-					"item_total":   itemTotal,
-				})
-				break
+	// Check products with their own cap, adding up repeated lines; each
+	// product is reported once, at the line that takes it over its cap
+	perProduct := make(map[string]int, len(lines))
+	overCap := make(map[string]map[string]interface{})
+	for _, line := range lines {
+		id := line.Product.ID
+		perProduct[id] += line.Quantity
+		if line.Product.MaxPerOrder == 0 || perProduct[id] <= line.Product.MaxPerOrder {
+			continue
+		}
+		p, ok := overCap[id]
+		if !ok {
+			p = map[string]interface{}{
+				"error":         "Product quantity exceeds its limit",
+				"product_id":    id,
+				"product_name":  line.Product.Name,
+				"max_per_order": line.Product.MaxPerOrder,
 			}
+			overCap[id] = p
+			problems = append(problems, p)
 		}
+		p["requested"] = perProduct[id]
+		p["message"] = localize(ctx, "product_quantity_limit", perProduct[id], line.Product.Name, line.Product.MaxPerOrder)
 	}
 
-	// Return structured data
-	result := map[string]interface{}{
-		"success":     true,
-		"total_price": total,
-		"items":       itemDetails,
-		"item_count":  len(itemDetails),
-		"message":     fmt.Sprintf("Total price is $%.2f", total),
+	// Check the quantity across all lines
+	if totalQuantity > maxOrderQuantity {
+		problems = append(problems, map[string]interface{}{
+			"error":              "Order quantity exceeds the limit",
+			"total_quantity":     totalQuantity,
+			"max_order_quantity": maxOrderQuantity,
+			"message":            localize(ctx, "order_quantity_limit", totalQuantity, maxOrderQuantity),
+		})
 	}
-	resultJSON, _ := json.Marshal(result)
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{mcp.NewTextContent(string(resultJSON))},
-	}, nil
+
+	if len(problems) > 0 {
+		messages := make([]string, len(problems))
+		for i, p := range problems {
+			messages[i] = p["message"].(string)
+		}
+		return nil, jsonError(map[string]interface{}{
+			"success":     false,
+			"error":       "Invalid items",
+			"errors":      problems,
+			"error_count": len(problems),
+			"message":     localize(ctx, "items_invalid", len(problems), strings.Join(messages, "; ")),
+		})
+	}
+	return lines, nil
 }
 
 /*
@@ -221,9 +493,12 @@ func calculateTotalHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.C
 	  "type": "object",
 	  "properties": {
 	    "total_price": {"type": "number"},
-	    "discount_percentage": {"type": "number"}
+	    "total_token": {"type": "string"},
+	    "discount_percentage": {"type": "number"},
+	    "mode": {"type": "string", "enum": ["keep", "off"]},
+	    "explain": {"type": "boolean"}
 	  },
-	  "required": ["total_price", "discount_percentage"]
+	  "required": ["discount_percentage"]
 	}
 */
 func applyDiscountHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -231,55 +506,234 @@ func applyDiscountHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.Ca
 	if args == nil {
 		return nil, fmt.Errorf("invalid arguments")
 	}
-	totalPrice, ok := args["total_price"].(float64)
-	if !ok {
-		return nil, fmt.Errorf("missing total_price")
-	}
 	discountPercentage, ok := args["discount_percentage"].(float64)
 	if !ok {
 		return nil, fmt.Errorf("missing discount_percentage")
 	}
 
-	// In Chinese, "打X折" means paying X% of the original price
-	// So discount_percentage represents the percentage to keep, not to subtract
-	discountedPrice := totalPrice * (discountPercentage / 100)
-	originalPrice := totalPrice
-	savedAmount := originalPrice - discountedPrice
+	// A total_token from calculate_total proves the total was computed by the
+	// server rather than made up, and takes precedence over total_price
+	totalPrice, hasTotal := args["total_price"].(float64)
+	token, _ := args["total_token"].(string)
+	if token != "" {
+		verified, err := verifyTotalToken(token)
+		if err != nil {
+			key := "total_token_invalid"
+			if errors.Is(err, errTotalTokenExpired) {
+				key = "total_token_expired"
+			}
+			return jsonError(map[string]interface{}{
+				"success": false,
+				"error":   "Invalid total token",
+				"reason":  err.Error(),
+				"message": localize(ctx, key),
+			}), nil
+		}
+		if hasTotal && roundMoney(totalPrice) != verified {
+			return jsonError(map[string]interface{}{
+				"success":        false,
+				"error":          "total_price does not match total_token",
+				"total_price":    totalPrice,
+				"verified_total": verified,
+				"message":        localize(ctx, "total_token_mismatch", money(totalPrice), money(verified)),
+			}), nil
+		}
+		totalPrice, hasTotal = verified, true
+	}
+	if !hasTotal {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "total_price or total_token is required",
+		}), nil
+	}
+
+	if discountPercentage < 0 || discountPercentage > 100 {
+		return jsonError(map[string]interface{}{
+			"success":             false,
+			"error":               "Invalid discount percentage",
+			"discount_percentage": discountPercentage,
+			"message":             localize(ctx, "discount_range"),
+		}), nil
+	}
+
+	// In Chinese, "打X折" means paying X% of the original price, so by default
+	// discount_percentage is the percentage to keep. Mode "off" treats it as
+	// the percentage taken off instead, as in "20% off".
+	mode, _ := args["mode"].(string)
+	if mode == "" {
+		mode = "keep"
+	}
+	keptPercentage := discountPercentage
+	if mode == "off" {
+		keptPercentage = 100 - discountPercentage
+	}
+	savedPercentage := 100 - keptPercentage
+
+	discountedPrice := roundMoney(totalPrice * (keptPercentage / 100))
+	originalPrice := roundMoney(totalPrice)
+	savedAmount := roundMoney(originalPrice - discountedPrice)
 
 	// Return structured data
 	result := map[string]interface{}{
 		"success":             true,
+		"tax_inclusive":       pricesIncludeTax,
+		"currency":            storeCurrency,
+		"mode":                mode,
 		"original_price":      originalPrice,
 		"discount_percentage": discountPercentage,
+		"kept_percentage":     keptPercentage,
+		"saved_percentage":    savedPercentage,
 		"discounted_price":    discountedPrice,
 		"saved_amount":        savedAmount,
-		"message":             fmt.Sprintf("Original price: $%.2f, After %.0f%% discount: $%.2f (You save: $%.2f)", originalPrice, discountPercentage, discountedPrice, savedAmount),
+		"total_verified":      token != "",
+		"message":             localize(ctx, "discount_applied", money(originalPrice), keptPercentage, savedPercentage, money(discountedPrice), money(savedAmount)),
 	}
-	resultJSON, _ := json.Marshal(result)
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{mcp.NewTextContent(string(resultJSON))},
-	}, nil
+	if explain, _ := args["explain"].(bool); explain {
+		result["explanation"] = explainDiscount(mode, discountPercentage, keptPercentage, totalPrice, discountedPrice, savedAmount)
+	}
+	return jsonResult(result), nil
 }
 
 func helpHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	helpText := `Available tools:
 
-1. get_price - Get the price of a product by ID
-   Parameters: product_id (string)
+1. get_price - Get the price of a product by ID, or by name or alias
+   Parameters: product_id (string) or lookup (string); the result's matched_on says whether lookup matched an id, name or alias
    Example: {"product_id": "1"}
+   Example: {"lookup": "筆電"}
 
 2. calculate_total - Calculate total price for multiple items
-   Parameters: items (array of {product_id, quantity})
+   Parameters: items (array of {product_id, quantity (optional, defaults to 1)}), tax_rate (number, optional), per_line_tax (boolean, optional), allow_preorder (boolean, optional)
    Example: {"items": [{"product_id": "1", "quantity": 2}]}
+   Example: {"items": [{"product_id": "1", "quantity": 2}, {"product_id": "3", "quantity": 1}], "tax_rate": 0.05, "per_line_tax": true}
 
 3. apply_discount - Apply discount to a total price
-   Parameters: total_price (number), discount_percentage (number)
-   Example: {"total_price": 1000, "discount_percentage": 30}
+   Parameters: total_price (number) or total_token (string from calculate_total), discount_percentage (number), mode ("keep" or "off", optional), explain (boolean, optional)
+   Example: {"total_price": 1000, "discount_percentage": 30} (打3折, pay $300)
+   Example: {"total_price": 1000, "discount_percentage": 30, "mode": "off"} (30% off, pay $700)
+
+4. place_order - Place an order and reserve stock for all items
+   Parameters: items (array of {product_id, quantity}), dry_run (boolean, optional), idempotency_key (string, optional), allow_preorder (boolean, optional)
+   Example: {"items": [{"product_id": "1", "quantity": 2}], "dry_run": true}
+
+5. parse_quantity - Convert a Chinese, English or Arabic numeral, or a unit like dozen or 打, to an integer
+   Parameters: text (string)
+   Example: {"text": "三十"}, {"text": "半打"}
+
+6. stats - Show call counts, error counts and latency percentiles per tool
+   Parameters: none
+
+7. shipping_cost - Calculate shipping from the order weight and destination zone, free above $2000
+   Parameters: items (array of {product_id, quantity}) or subtotal (number), destination (string, optional)
+   Example: {"items": [{"product_id": "2", "quantity": 3}], "destination": "asia"}
+
+8. apply_discounts - Apply several discounts with a stacking rule
+   Parameters: total_price (number), discounts (array of {type, value}), stacking ("sequential" or "best")
+   Example: {"total_price": 1000, "discounts": [{"type": "percentage", "value": 80}, {"type": "flat", "value": 50}], "stacking": "sequential"}
+
+9. add_gift_wrap - Add a gift wrapping fee to a total price
+   Parameters: total_price (number), items_count (integer, optional)
+   Example: {"total_price": 1500, "items_count": 2}
+
+10. earn_points - Earn loyalty points for a purchase subtotal
+   Parameters: subtotal (number), session_id (string, optional)
+   Example: {"subtotal": 1500}
+
+11. redeem_points - Redeem loyalty points as a discount on a total price
+   Parameters: points (integer), total_price (number), session_id (string, optional)
+   Example: {"points": 500, "total_price": 1500}
+
+12. get_price_history - Show how a product's price has changed over time
+   Parameters: product_id (string)
+   Example: {"product_id": "1"}
+
+13. ping - Check that tool calls reach the server
+   Parameters: none
+
+14. validate_order - Check an order against the limits, minimum order value and stock without placing it
+   Parameters: items (array of {product_id, quantity}), allow_preorder (boolean, optional)
+   Example: {"items": [{"product_id": "3", "quantity": 1}]}
+
+15. get_server_info - Show the server version, build commit, uptime and catalog size
+   Parameters: none
+
+16. wishlist_add - Save a product to the wishlist for later; saving it again does nothing
+   Parameters: product_id (string), session_id (string, optional)
+   Example: {"product_id": "2"}
+
+17. wishlist_list - List the saved products with their current prices, flagging price drops since they were saved
+   Parameters: session_id (string, optional)
+
+18. get_cheapest - Find the cheapest product, optionally within a category
+   Parameters: category (string, optional)
+   Example: {"category": "mobile"}
+
+19. get_most_expensive - Find the most expensive product, optionally within a category
+   Parameters: category (string, optional)
+
+20. get_tool_schema - Get the full input schema of one tool
+   Parameters: tool_name (string)
+   Example: {"tool_name": "calculate_total"}
+
+21. get_product_details - Get a product's category, stock, weight, order limit, availability and attributes such as ram, screen and color
+   Parameters: product_id (string)
+   Example: {"product_id": "1"}
+
+22. compare_by_attribute - Compare one attribute of two products, such as their screen size
+   Parameters: product_id_a (string), product_id_b (string), attribute (string)
+   Example: {"product_id_a": "3", "product_id_b": "2", "attribute": "screen"}
+
+23. list_products - List the catalog a page at a time; pass next_offset as offset for the next page
+   Parameters: limit (integer, optional, 1-100, default 20), offset (integer, optional), sort_by ("price_asc", "price_desc" or "name", optional)
+   Example: {"limit": 2, "offset": 2, "sort_by": "price_asc"}
+
+24. split_bill - Split a total between people, evenly or by shares; the parts always add up to the total
+   Parameters: total_price (number), people (integer, optional), shares (array of numbers, optional)
+   Example: {"total_price": 100, "people": 3}
+
+25. resolve_alias - Find the product meant by a name such as 筆電 or smartphone
+   Parameters: alias (string)
+   Example: {"alias": "筆記型電腦"}
+
+26. cancel_order - Cancel a placed order, return its items to stock and refund its total
+   Parameters: order_id (string)
+   Example: {"order_id": "ORD-0001"}
+
+27. get_receipt - Get a printable receipt of a placed order as plain text or markdown
+   Parameters: order_id (string), format ("text" or "markdown", optional)
+   Example: {"order_id": "ORD-0001", "format": "markdown"}
+
+28. get_product_image - Get the image URL and alt text of a product for display
+   Parameters: product_id (string)
+   Example: {"product_id": "1"}
+
+Admin tools (only when the server runs with -admin or STORE_ADMIN=1):
+
+- update_price - Change the price of a product
+   Parameters: product_id (string), new_price (number)
+   Example: {"product_id": "1", "new_price": 900}
+
+- add_product - Add a new product to the catalog
+   Parameters: id (string), name (string), price (number), category (string, optional), stock (integer, optional), max_per_order (integer, optional), attributes (object of strings, optional), image_url (string, optional)
+   Example: {"id": "5", "name": "Headphones", "price": 150, "category": "audio", "stock": 20, "max_per_order": 10, "attributes": {"color": "white"}, "image_url": "https://example.com/headphones.jpg"}
+
+- remove_product - Remove a product from the catalog
+   Parameters: product_id (string)
+   Example: {"product_id": "5"}
+
+- import_catalog - Replace the whole catalog; nothing changes if any product is invalid
+   Parameters: products (array of {id, name, price, category, stock, weight, max_per_order, attributes, image_url})
+   Example: {"products": [{"id": "1", "name": "Laptop", "price": 950, "stock": 5}]}
+
+- get_audit_log - List price changes, product additions and removals, and placed orders
+   Parameters: since (RFC3339 string, optional), until (RFC3339 string, optional)
+   Example: {"since": "2024-01-02T00:00:00Z"}
 
 Product IDs:
 - "1": Laptop ($1000)
 - "2": Smartphone ($500)
 - "3": Tablet ($300)
+- "4": Smartwatch ($250, preorder only until its release date)
 
 Note: discount_percentage represents the percentage to keep (e.g., 30 for 30% of original price)`
 
@@ -289,36 +743,127 @@ Note: discount_percentage represents the percentage to keep (e.g., 30 for 30% of
 }
 
 func main() {
+	httpAddr := flag.String("http", "", "serve over HTTP/SSE on this address (e.g. :8080) instead of stdio")
+	admin := flag.Bool("admin", false, "register the catalog mutation tools (also enabled by STORE_ADMIN=1)")
+	toolList := flag.String("tools", "", "comma separated list of the tools to register (also set by STORE_TOOLS); empty registers all")
+	seed := flag.Bool("seed", false, "load the fixed test catalog instead of the default products (also enabled by STORE_SEED=1)")
+	catalogFrom := flag.String("catalog-url", "", "fetch the catalog from this URL at startup, keeping the default products if it fails (also set by STORE_CATALOG_URL)")
+	maxConcurrent := flag.Int("max-concurrent", 0, "the most tool calls to run at once, queuing the rest (also set by STORE_MAX_CONCURRENT_CALLS); 0 means no limit")
+	flag.Parse()
+
+	// Apply settings from the environment
+	loadConfig()
+	if *admin {
+		adminEnabled = true
+	}
+	if *toolList != "" {
+		enabledTools = parseToolList(*toolList)
+	}
+	if *seed {
+		seedFixtures = true
+	}
+	if *catalogFrom != "" {
+		catalogURL = *catalogFrom
+	}
+	if *maxConcurrent > 0 {
+		maxConcurrentCalls = *maxConcurrent
+	}
+	loadCatalog()
+	startSessionJanitor()
+	startConcurrencyLimit()
+
 	// Create a new MCP server instance
 	s := server.NewMCPServer(
-		"Product Price Server",
-		"1.0.0",
+		serverName,
+		serverVersion,
 		server.WithToolCapabilities(false),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(requestLogHooks()),
+		// Registered first so it is the outermost middleware and also
+		// catches panics in the middleware below
+		server.WithToolHandlerMiddleware(recoveryMiddleware),
+		server.WithToolHandlerMiddleware(metricsMiddleware),
+		server.WithToolHandlerMiddleware(tracingMiddleware),
+		server.WithToolHandlerMiddleware(localeMiddleware),
+		server.WithToolHandlerMiddleware(businessHoursMiddleware),
+		server.WithToolHandlerMiddleware(rateLimitMiddleware),
+		server.WithToolHandlerMiddleware(concurrencyMiddleware),
+		server.WithToolHandlerMiddleware(resultSizeMiddleware),
+		server.WithToolHandlerMiddleware(validationMiddleware),
 	)
 
+	// Define the shopping assistant prompt used by the client as its system prompt
+	shoppingAssistantPrompt := mcp.NewPrompt("shopping_assistant",
+		mcp.WithPromptDescription("System prompt that maps Chinese shopping queries to the store's tools"),
+	)
+
+	// Add the shopping assistant prompt with its handler
+	s.AddPrompt(shoppingAssistantPrompt, shoppingAssistantPromptHandler)
+
 	// Define the help tool
 	helpTool := mcp.NewTool("help",
 		mcp.WithDescription("Show all supported operations and examples"),
 	)
 
 	// Add the help tool with its handler
-	s.AddTool(helpTool, helpHandler)
+	addTool(s, helpTool, helpHandler)
 
 	// Define the get_price tool
 	getPriceTool := mcp.NewTool("get_price",
-		mcp.WithDescription(`Get the price of a product by its ID.
+		mcp.WithDescription(`Get the price of a product by its ID, or by its name or alias with lookup.
 Product mapping:
 - Laptop -> ID: "1", Price: $1000.0
 - Smartphone -> ID: "2", Price: $500.0
-- Tablet -> ID: "3", Price: $300.0`),
+- Tablet -> ID: "3", Price: $300.0
+- Smartwatch -> ID: "4", Price: $250.0 (preorder)`),
 		mcp.WithString("product_id",
-			mcp.Required(),
 			mcp.Description("The ID of the product to get the price of"),
 		),
+		mcp.WithString("lookup",
+			mcp.Description("A product ID, name or alias such as 筆電 when the ID is not known; tried as an ID, then an exact name, then an alias. Ignored when product_id is given."),
+		),
 	)
 
 	// Add the get_price tool with its handler
-	s.AddTool(getPriceTool, getPriceHandler)
+	addTool(s, getPriceTool, getPriceHandler)
+
+	// Define the get_product_details tool
+	getProductDetailsTool := mcp.NewTool("get_product_details",
+		mcp.WithDescription(`Get everything known about a product: price, category, stock, weight, order limit, availability and its attributes such as ram, storage, screen and color.
+Use it for spec questions like "which laptop has 16GB RAM?".`),
+		mcp.WithString("product_id",
+			mcp.Required(),
+			mcp.Description("The ID of the product"),
+		),
+	)
+
+	// Add the get_product_details tool with its handler
+	addTool(s, getProductDetailsTool, getProductDetailsHandler)
+
+	// Define the compare_by_attribute tool
+	compareByAttributeTool := mcp.NewTool("compare_by_attribute",
+		mcp.WithDescription(`Compare one attribute of two products, e.g. which of the tablet and the smartphone has the bigger screen.
+Returns each product's value; when both values share a unit, larger_product_id names the product with the larger one.`),
+		mcp.WithString("product_id_a", mcp.Required(), mcp.Description("The ID of the first product")),
+		mcp.WithString("product_id_b", mcp.Required(), mcp.Description("The ID of the second product")),
+		mcp.WithString("attribute", mcp.Required(), mcp.Description("The attribute name, such as screen, ram, storage or color")),
+	)
+
+	// Add the compare_by_attribute tool with its handler
+	addTool(s, compareByAttributeTool, compareByAttributeHandler)
+
+	// Define the resolve_alias tool
+	resolveAliasTool := mcp.NewTool("resolve_alias",
+		mcp.WithDescription(`Find the product a shopper means by a name, e.g. "筆電" or "手機" -> its product_id.
+Matches product IDs, the store's alias table and catalog names, ignoring case. Use it when a product name is not in the product mapping.`),
+		mcp.WithString("alias",
+			mcp.Required(),
+			mcp.Description("The name the shopper used, e.g. \"筆記型電腦\""),
+		),
+	)
+
+	// Add the resolve_alias tool with its handler
+	addTool(s, resolveAliasTool, resolveAliasHandler)
 
 	// Define the calculate_total tool
 	calculateTotalTool := mcp.NewTool("calculate_total",
@@ -326,7 +871,8 @@ Product mapping:
 Product mapping:
 - Laptop -> ID: "1", Price: $1000.0
 - Smartphone -> ID: "2", Price: $500.0
-- Tablet -> ID: "3", Price: $300.0`),
+- Tablet -> ID: "3", Price: $300.0
+- Smartwatch -> ID: "4", Price: $250.0 (preorder)`),
 		mcp.WithArray("items",
 			mcp.Required(),
 			mcp.Description("Array of items with product_id and quantity"),
@@ -338,17 +884,26 @@ Product mapping:
 						"description": "The ID of the product",
 					},
 					"quantity": map[string]any{
-						"type":        "integer",
-						"description": "The quantity of the product",
+						"type":        []string{"integer", "string"},
+						"description": "The quantity of the product, as a number or a numeral string such as \"三十\"; when omitted 1 is assumed and the line is marked defaulted_quantity",
 					},
 				},
-				"required": []string{"product_id", "quantity"},
+				"required": []string{"product_id"},
 			}),
 		),
+		mcp.WithNumber("tax_rate",
+			mcp.Description("Optional tax rate for this calculation, e.g. 0.05 for 5%; defaults to the store's rate"),
+		),
+		mcp.WithBoolean("per_line_tax",
+			mcp.Description("Also report the tax of each item; the item taxes always add up to the order tax"),
+		),
+		mcp.WithBoolean("allow_preorder",
+			mcp.Description("Accept products that are not released yet; without it such items are rejected with their release date"),
+		),
 	)
 
 	// Add the calculate_total tool with its handler
-	s.AddTool(calculateTotalTool, calculateTotalHandler)
+	addTool(s, calculateTotalTool, calculateTotalHandler)
 
 	// Define the apply_discount tool
 	applyDiscountTool := mcp.NewTool("apply_discount",
@@ -356,13 +911,449 @@ Product mapping:
 In Chinese context, "打X折" means paying X% of the original price.
 For example:
 - "打3折" (30% discount) means paying 30% of original price, saving 70%
-- "打8折" (80% discount) means paying 80% of original price, saving 20%`),
-		mcp.WithNumber("total_price", mcp.Required(), mcp.Description("The total price to apply the discount to")),
-		mcp.WithNumber("discount_percentage", mcp.Required(), mcp.Description("The percentage to keep (e.g., 30 for 打3折, 80 for 打8折)")),
+- "打8折" (80% discount) means paying 80% of original price, saving 20%
+Use mode "off" for discounts phrased as the amount taken off, e.g. "20% off" or "減20%".
+After calculate_total, pass its total_token instead of copying total_price.`),
+		mcp.WithNumber("total_price", mcp.Description("The total price to apply the discount to; required unless total_token is given")),
+		mcp.WithString("total_token", mcp.Description("The total_token returned by calculate_total; preferred over total_price because the server verifies it")),
+		mcp.WithNumber("discount_percentage", mcp.Required(), mcp.Description("The percentage to keep (e.g., 30 for 打3折, 80 for 打8折), or the percentage taken off in mode off")),
+		mcp.WithString("mode",
+			mcp.Enum("keep", "off"),
+			mcp.Description(`How discount_percentage is read: "keep" pays that percentage (打X折), "off" subtracts it (X% off). Defaults to keep.`),
+		),
+		mcp.WithBoolean("explain",
+			mcp.Description("Also return the arithmetic step by step in an explanation object, for showing the user how the price was worked out"),
+		),
 	)
 
 	// Add the apply_discount tool with its handler
-	s.AddTool(applyDiscountTool, applyDiscountHandler)
+	addTool(s, applyDiscountTool, applyDiscountHandler)
+
+	// Define the place_order tool
+	placeOrderTool := mcp.NewTool("place_order",
+		mcp.WithDescription(`Place an order for multiple items and decrement their stock.
+The order fails as a whole, with no stock taken, if any item lacks sufficient stock.
+Product mapping:
+- Laptop -> ID: "1", Price: $1000.0
+- Smartphone -> ID: "2", Price: $500.0
+- Tablet -> ID: "3", Price: $300.0
+- Smartwatch -> ID: "4", Price: $250.0 (preorder)`),
+		mcp.WithArray("items",
+			mcp.Required(),
+			mcp.Description("Array of items with product_id and quantity"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"product_id": map[string]any{
+						"type":        "string",
+						"description": "The ID of the product",
+					},
+					"quantity": map[string]any{
+						"type":        []string{"integer", "string"},
+						"description": "The quantity of the product, as a number or a numeral string such as \"三十\"",
+					},
+				},
+				"required": []string{"product_id", "quantity"},
+			}),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Validate the order and preview totals and stock impact without placing it (default false)"),
+		),
+		mcp.WithString("idempotency_key",
+			mcp.Description("Optional unique key for this order; retrying with the same key returns the original order instead of placing a new one"),
+		),
+		mcp.WithBoolean("allow_preorder",
+			mcp.Description("Accept products that are not released yet; without it such items are rejected with their release date"),
+		),
+	)
+
+	// Add the place_order tool with its handler
+	addTool(s, placeOrderTool, placeOrderHandler)
+
+	// Define the cancel_order tool
+	cancelOrderTool := mcp.NewTool("cancel_order",
+		mcp.WithDescription("Cancel a placed order, return its items to stock and refund its total. An order can only be cancelled once."),
+		mcp.WithString("order_id",
+			mcp.Required(),
+			mcp.Description("The ID place_order returned, such as ORD-0001"),
+		),
+	)
+
+	// Add the cancel_order tool with its handler
+	addTool(s, cancelOrderTool, cancelOrderHandler)
+
+	// Define the get_receipt tool
+	getReceiptTool := mcp.NewTool("get_receipt",
+		mcp.WithDescription("Get a printable receipt of a placed order: its lines, subtotal, tax and total, formatted as plain text or markdown. Show the receipt to the user as is."),
+		mcp.WithString("order_id",
+			mcp.Required(),
+			mcp.Description("The ID place_order returned, such as ORD-0001"),
+		),
+		mcp.WithString("format",
+			mcp.Description("The layout of the receipt (default text)"),
+			mcp.Enum(receiptFormats...),
+		),
+	)
+
+	// Add the get_receipt tool with its handler
+	addTool(s, getReceiptTool, getReceiptHandler)
+
+	// Define the get_product_image tool
+	getProductImageTool := mcp.NewTool("get_product_image",
+		mcp.WithDescription("Get the image URL and alt text of a product, for clients that can show pictures. Products without an image return Image not found."),
+		mcp.WithString("product_id",
+			mcp.Required(),
+			mcp.Description("The ID of the product"),
+		),
+	)
+
+	// Add the get_product_image tool with its handler
+	addTool(s, getProductImageTool, getProductImageHandler)
+
+	// Define the get_server_info tool
+	getServerInfoTool := mcp.NewTool("get_server_info",
+		mcp.WithDescription("Show the server name, version, build commit, uptime, catalog size and enabled capabilities"),
+	)
+
+	// Add the get_server_info tool with its handler
+	addTool(s, getServerInfoTool, getServerInfoHandler)
+
+	// Define the validate_order tool
+	validateOrderTool := mcp.NewTool("validate_order",
+		mcp.WithDescription(`Check whether an order could be placed without placing it.
+Runs the same checks as place_order: known products, release dates, quantity limits, the minimum order value and stock.`),
+		mcp.WithArray("items",
+			mcp.Required(),
+			mcp.Description("Array of items with product_id and quantity"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"product_id": map[string]any{
+						"type":        "string",
+						"description": "The ID of the product",
+					},
+					"quantity": map[string]any{
+						"type":        []string{"integer", "string"},
+						"description": "The quantity of the product, as a number or a numeral string such as \"三十\"",
+					},
+				},
+				"required": []string{"product_id", "quantity"},
+			}),
+		),
+		mcp.WithBoolean("allow_preorder",
+			mcp.Description("Accept products that are not released yet; without it such items are rejected with their release date"),
+		),
+	)
+
+	// Add the validate_order tool with its handler
+	addTool(s, validateOrderTool, validateOrderHandler)
+
+	// Define the parse_quantity tool
+	parseQuantityTool := mcp.NewTool("parse_quantity",
+		mcp.WithDescription(`Convert a quantity written in Chinese, English or Arabic numerals, or as counted units, to an integer of at least 1.
+For example: "三十" -> 30, "十五" -> 15, "一百零五" -> 105, "12" -> 12, "twenty-five" -> 25,
+"a dozen" -> 12, "half a dozen" -> 6, "3 pairs" -> 6, "一打" -> 12, "半打" -> 6, "兩雙" -> 4`),
+		mcp.WithString("text",
+			mcp.Required(),
+			mcp.Description("The numeral text to convert"),
+		),
+	)
+
+	// Add the parse_quantity tool with its handler
+	addTool(s, parseQuantityTool, parseQuantityHandler)
+
+	// Define the stats tool
+	statsTool := mcp.NewTool("stats",
+		mcp.WithDescription("Show per-tool invocation counts, error counts and latency percentiles since the server started"),
+	)
+
+	// Add the stats tool with its handler
+	addTool(s, statsTool, statsHandler)
+
+	// Define the shipping_cost tool
+	shippingCostTool := mcp.NewTool("shipping_cost",
+		mcp.WithDescription(`Calculate the shipping cost of an order from its total weight.
+Weight brackets: up to 1kg $5, up to 5kg $10, up to 20kg $25, heavier $60.
+The bracket cost is multiplied by the destination zone's multiplier; orders without a destination ship domestic.
+Shipping is free when the subtotal is $2000 or more, whatever the destination.
+Pass items to compute weight and subtotal, or only a subtotal when the items are unknown.`),
+		mcp.WithArray("items",
+			mcp.Description("Array of items with product_id and quantity"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"product_id": map[string]any{
+						"type":        "string",
+						"description": "The ID of the product",
+					},
+					"quantity": map[string]any{
+						"type":        []string{"integer", "string"},
+						"description": "The quantity of the product, as a number or a numeral string such as \"三十\"",
+					},
+				},
+				"required": []string{"product_id", "quantity"},
+			}),
+		),
+		mcp.WithNumber("subtotal", mcp.Description("The order subtotal, used when items are not given")),
+		mcp.WithString("destination", mcp.Description("The shipping zone: "+strings.Join(shippingZoneNames(), ", ")+" (default "+defaultShippingZone+")")),
+	)
+
+	// Add the shipping_cost tool with its handler
+	addTool(s, shippingCostTool, shippingCostHandler)
+
+	// Define the apply_discounts tool
+	applyDiscountsTool := mcp.NewTool("apply_discounts",
+		mcp.WithDescription(`Apply several discounts to a total price.
+Each discount is either "percentage" (the percentage to keep, as in 打X折) or "flat" (an amount to subtract).
+Stacking "sequential" applies the discounts one after another in the given order;
+stacking "best" applies only the single discount giving the lowest price.`),
+		mcp.WithNumber("total_price", mcp.Required(), mcp.Description("The total price to apply the discounts to")),
+		mcp.WithArray("discounts",
+			mcp.Required(),
+			mcp.Description("Array of discounts with type and value"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"type": map[string]any{
+						"type":        "string",
+						"enum":        []string{"percentage", "flat"},
+						"description": "percentage keeps value% of the price, flat subtracts value",
+					},
+					"value": map[string]any{
+						"type":        "number",
+						"description": "The percentage to keep or the amount to subtract",
+					},
+				},
+				"required": []string{"type", "value"},
+			}),
+		),
+		mcp.WithString("stacking",
+			mcp.Enum("sequential", "best"),
+			mcp.Description("How the discounts combine (default sequential)"),
+		),
+	)
+
+	// Add the apply_discounts tool with its handler
+	addTool(s, applyDiscountsTool, applyDiscountsHandler)
+
+	// Define the add_gift_wrap tool
+	addGiftWrapTool := mcp.NewTool("add_gift_wrap",
+		mcp.WithDescription(`Add a gift wrapping fee to a total price.
+With items_count each item is wrapped separately for $3.00 per item;
+without it the whole order is wrapped together for a flat $10.00.`),
+		mcp.WithNumber("total_price", mcp.Required(), mcp.Description("The total price to add the wrapping fee to")),
+		mcp.WithNumber("items_count", mcp.Description("Number of items to wrap separately")),
+	)
+
+	// Add the add_gift_wrap tool with its handler
+	addTool(s, addGiftWrapTool, addGiftWrapHandler)
+
+	// Define the split_bill tool
+	splitBillTool := mcp.NewTool("split_bill",
+		mcp.WithDescription(`Split a total price between several people for a group purchase.
+Give people for an equal split, or shares such as [2, 1, 1] for an uneven one.
+Amounts are in whole cents (the smallest unit of the store currency) and always add up exactly to the total; leftover cents go to the largest remainders, earlier people first.`),
+		mcp.WithNumber("total_price", mcp.Required(), mcp.Description("The total price to split")),
+		mcp.WithNumber("people", mcp.Description(fmt.Sprintf("How many people pay, from 1 to %d; optional when shares is given", maxSplitPeople))),
+		mcp.WithArray("shares",
+			mcp.Description("Optional relative share of each person, e.g. [2, 1, 1] for one person paying half"),
+			mcp.Items(map[string]any{"type": "number"}),
+		),
+	)
+
+	// Add the split_bill tool with its handler
+	addTool(s, splitBillTool, splitBillHandler)
+
+	// Define the earn_points tool
+	earnPointsTool := mcp.NewTool("earn_points",
+		mcp.WithDescription(`Earn loyalty points for a purchase.
+Each $1 of subtotal earns 1 point by default; points are kept per session.`),
+		mcp.WithNumber("subtotal", mcp.Required(), mcp.Description("The purchase subtotal that earns points")),
+		mcp.WithString("session_id", mcp.Description("The shopper's session; defaults to the current connection")),
+	)
+
+	// Add the earn_points tool with its handler
+	addTool(s, earnPointsTool, earnPointsHandler)
+
+	// Define the redeem_points tool
+	redeemPointsTool := mcp.NewTool("redeem_points",
+		mcp.WithDescription(`Redeem loyalty points as a discount on a total price.
+Each point is worth $0.01 by default. The discount never exceeds the total,
+and only the points needed to cover the total are used.`),
+		mcp.WithNumber("points", mcp.Required(), mcp.Description("The number of points to redeem")),
+		mcp.WithNumber("total_price", mcp.Required(), mcp.Description("The total price to apply the points to")),
+		mcp.WithString("session_id", mcp.Description("The shopper's session; defaults to the current connection")),
+	)
+
+	// Add the redeem_points tool with its handler
+	addTool(s, redeemPointsTool, redeemPointsHandler)
+
+	// Define the get_price_history tool
+	getPriceHistoryTool := mcp.NewTool("get_price_history",
+		mcp.WithDescription("Get the log of price changes (timestamp, old price, new price) for a product, oldest first"),
+		mcp.WithString("product_id",
+			mcp.Required(),
+			mcp.Description("The ID of the product to get the price history of"),
+		),
+	)
+
+	// Add the get_price_history tool with its handler
+	addTool(s, getPriceHistoryTool, getPriceHistoryHandler)
+
+	// Define the ping tool
+	pingTool := mcp.NewTool("ping",
+		mcp.WithDescription("Connectivity check: returns pong and the current server time. Takes no arguments."),
+	)
+
+	// Add the ping tool with its handler
+	addTool(s, pingTool, pingHandler)
+
+	// Define the wishlist_add tool
+	wishlistAddTool := mcp.NewTool("wishlist_add",
+		mcp.WithDescription("Save a product to the shopper's wishlist for later. Saving a product that is already on the wishlist does nothing."),
+		mcp.WithString("product_id",
+			mcp.Required(),
+			mcp.Description("The ID of the product to save"),
+		),
+		mcp.WithString("session_id", mcp.Description("The shopper's session; defaults to the current connection")),
+	)
+
+	// Add the wishlist_add tool with its handler
+	addTool(s, wishlistAddTool, wishlistAddHandler)
+
+	// Define the wishlist_list tool
+	wishlistListTool := mcp.NewTool("wishlist_list",
+		mcp.WithDescription("List the products on the shopper's wishlist in the order they were saved, with the price when saved, the current price and whether the price has dropped since"),
+		mcp.WithString("session_id", mcp.Description("The shopper's session; defaults to the current connection")),
+	)
+
+	// Add the wishlist_list tool with its handler
+	addTool(s, wishlistListTool, wishlistListHandler)
+
+	// Define the get_cheapest tool
+	getCheapestTool := mcp.NewTool("get_cheapest",
+		mcp.WithDescription("Find the cheapest product in the catalog, or in one category. When several products share the price, the lowest ID wins."),
+		mcp.WithString("category", mcp.Description("Optional category to search, e.g. \"mobile\"")),
+	)
+
+	// Add the get_cheapest tool with its handler
+	addTool(s, getCheapestTool, getCheapestHandler)
+
+	// Define the get_most_expensive tool
+	getMostExpensiveTool := mcp.NewTool("get_most_expensive",
+		mcp.WithDescription("Find the most expensive product in the catalog, or in one category. When several products share the price, the lowest ID wins."),
+		mcp.WithString("category", mcp.Description("Optional category to search, e.g. \"mobile\"")),
+	)
+
+	// Add the get_most_expensive tool with its handler
+	addTool(s, getMostExpensiveTool, getMostExpensiveHandler)
+
+	// Define the list_products tool
+	listProductsTool := mcp.NewTool("list_products",
+		mcp.WithDescription(`List the products in the catalog one page at a time, in catalog order unless sort_by is given.
+Pass the returned next_offset as offset, with the same sort_by, to get the next page; it is null on the last page.`),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Products per page, from 1 to %d (default %d)", maxListLimit, defaultListLimit))),
+		mcp.WithNumber("offset", mcp.Description("Number of products to skip (default 0)")),
+		mcp.WithString("sort_by",
+			mcp.Enum(productSortKeys...),
+			mcp.Description(`"price_asc" for cheapest first, "price_desc" for most expensive first, or "name" for alphabetical; products that tie keep catalog order`),
+		),
+	)
+
+	// Add the list_products tool with its handler
+	addTool(s, listProductsTool, listProductsHandler)
+
+	// Define the get_tool_schema tool
+	getToolSchemaTool := mcp.NewTool("get_tool_schema",
+		mcp.WithDescription("Get the full JSON input schema of a single tool, e.g. to build a form or validate arguments locally without fetching the whole tool list"),
+		mcp.WithString("tool_name",
+			mcp.Required(),
+			mcp.Description("The name of the tool, as listed by tools/list"),
+		),
+	)
+
+	// Add the get_tool_schema tool with its handler
+	addTool(s, getToolSchemaTool, getToolSchemaHandler)
+
+	// Catalog mutation tools are only available to admins
+	if adminEnabled {
+		// Define the update_price tool
+		updatePriceTool := mcp.NewTool("update_price",
+			mcp.WithDescription("Admin: change the price of a product. The change is recorded in its price history."),
+			mcp.WithString("product_id",
+				mcp.Required(),
+				mcp.Description("The ID of the product to update"),
+			),
+			mcp.WithNumber("new_price", mcp.Required(), mcp.Description("The new price, zero or greater")),
+		)
+
+		// Add the update_price tool with its handler
+		addTool(s, updatePriceTool, updatePriceHandler)
+
+		// Define the add_product tool
+		addProductTool := mcp.NewTool("add_product",
+			mcp.WithDescription("Admin: add a new product to the catalog. Fails if the ID is already in use."),
+			mcp.WithString("id", mcp.Required(), mcp.Description("The unique ID of the new product")),
+			mcp.WithString("name", mcp.Required(), mcp.Description("The product name")),
+			mcp.WithNumber("price", mcp.Required(), mcp.Description("The price, zero or greater")),
+			mcp.WithString("category", mcp.Description("Optional product category")),
+			mcp.WithNumber("stock", mcp.Description("Optional initial stock, defaults to 0")),
+			mcp.WithNumber("max_per_order", mcp.Description("Optional largest quantity of this product per order, 0 uses the global limit")),
+			mcp.WithObject("attributes", mcp.Description(`Optional specs as string values, e.g. {"ram": "16GB", "color": "silver"}`)),
+			mcp.WithString("image_url", mcp.Description("Optional absolute http or https URL of a product picture")),
+		)
+
+		// Add the add_product tool with its handler
+		addTool(s, addProductTool, addProductHandler)
+
+		// Define the remove_product tool
+		removeProductTool := mcp.NewTool("remove_product",
+			mcp.WithDescription("Admin: remove a product from the catalog and report whether it existed"),
+			mcp.WithString("product_id", mcp.Required(), mcp.Description("The ID of the product to remove")),
+		)
+
+		// Add the remove_product tool with its handler
+		addTool(s, removeProductTool, removeProductHandler)
+
+		// Define the import_catalog tool
+		importCatalogTool := mcp.NewTool("import_catalog",
+			mcp.WithDescription("Admin: replace the whole catalog with the given products. Nothing changes unless every product is valid; all problems are reported together."),
+			mcp.WithArray("products",
+				mcp.Required(),
+				mcp.Description("The new catalog"),
+				mcp.Items(map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"id":             map[string]any{"type": "string", "description": "Unique product ID"},
+						"name":           map[string]any{"type": "string", "description": "Product name"},
+						"price":          map[string]any{"type": "number", "description": "Price, zero or greater"},
+						"category":       map[string]any{"type": "string", "description": "Optional category"},
+						"stock":          map[string]any{"type": "integer", "description": "Optional stock, defaults to 0"},
+						"weight":         map[string]any{"type": "number", "description": "Optional weight in kg, defaults to 0"},
+						"max_per_order":  map[string]any{"type": "integer", "description": "Optional largest quantity per order, 0 uses the global limit"},
+						"attributes":     map[string]any{"type": "object", "description": "Optional specs as string values, e.g. {\"ram\": \"16GB\"}"},
+						"image_url":      map[string]any{"type": "string", "description": "Optional absolute http or https URL of a product picture"},
+						"available_from": map[string]any{"type": "string", "description": "Optional release date as RFC 3339 or YYYY-MM-DD; the product can only be preordered until then"},
+					},
+					"required": []string{"id", "name", "price"},
+				}),
+			),
+		)
+
+		// Add the import_catalog tool with its handler
+		addTool(s, importCatalogTool, importCatalogHandler)
+
+		// Define the get_audit_log tool
+		getAuditLogTool := mcp.NewTool("get_audit_log",
+			mcp.WithDescription("Admin: list mutating operations (price changes, product additions and removals, placed orders) with who made them, old and new values, oldest first"),
+			mcp.WithString("since", mcp.Description("Optional RFC3339 timestamp; only entries at or after it are returned")),
+			mcp.WithString("until", mcp.Description("Optional RFC3339 timestamp; only entries at or before it are returned")),
+		)
+
+		// Add the get_audit_log tool with its handler
+		addTool(s, getAuditLogTool, getAuditLogHandler)
+	}
+	logEnabledTools()
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -373,6 +1364,16 @@ For example:
 		os.Exit(0)
 	}()
 
+	// Serve over HTTP/SSE when an address is given, otherwise over stdio
+	if *httpAddr != "" {
+		serverTransport = "http"
+		if err := serveHTTP(s, *httpAddr); err != nil {
+			log.Printf("Server error: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Start the server using stdio
 	if err := server.ServeStdio(s); err != nil {
 		fmt.Printf("Server error: %v\n", err)
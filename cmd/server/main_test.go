@@ -0,0 +1,115 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestQuantityLimits(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &maxLineQuantity, 10)
+	setVar(t, &maxOrderQuantity, 15)
+
+	tests := []struct {
+		name   string
+		items  []interface{}
+		errors []string
+	}{
+		{"line at its limit", itemsArg("1", 10), nil},
+		{"line over its limit", itemsArg("1", 11), []string{"Quantity cannot exceed 10"}},
+		{"order at its limit", itemsArg("1", 10, "2", 5), nil},
+		{"order over its limit", itemsArg("1", 10, "2", 6), []string{"Order quantity exceeds the limit"}},
+		{"order over its limit across many lines", itemsArg("1", 4, "2", 4, "3", 4, "1", 4), []string{"Order quantity exceeds the limit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callTool(t, calculateTotalHandler, map[string]interface{}{"items": tt.items})
+			if tt.errors == nil {
+				if result["success"] != true {
+					t.Fatalf("got %v, want success", result)
+				}
+				return
+			}
+			if result["error"] != "Invalid items" || !reflect.DeepEqual(errorNames(result), tt.errors) {
+				t.Fatalf("got %v, want Invalid items with %v", result, tt.errors)
+			}
+		})
+	}
+}
+
+func TestOrderQuantityLimitReportsTotal(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &maxOrderQuantity, 15)
+
+	result := callTool(t, placeOrderHandler, map[string]interface{}{"items": itemsArg("1", 8, "2", 8)})
+	problems, _ := result["errors"].([]interface{})
+	if len(problems) != 1 {
+		t.Fatalf("got %v, want one problem", result)
+	}
+	problem := problems[0].(map[string]interface{})
+	if problem["total_quantity"] != float64(16) || problem["max_order_quantity"] != float64(15) {
+		t.Errorf("problem is %v, want total_quantity 16 and max_order_quantity 15", problem)
+	}
+	if product, _ := catalog.Get("1"); product.Stock != 10000 {
+		t.Errorf("stock is %d after a rejected order, want 10000", product.Stock)
+	}
+}
+
+func TestLineItemLimit(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &maxLineItems, 5)
+
+	atLimit := itemsArg("1", 1, "2", 1, "3", 1, "1", 1, "2", 1)
+	if result := callTool(t, calculateTotalHandler, map[string]interface{}{"items": atLimit}); result["success"] != true {
+		t.Errorf("%d items got %v, want success", len(atLimit), result)
+	}
+
+	overLimit := append(atLimit, itemsArg("3", 1)...)
+	for _, tool := range []struct {
+		name    string
+		handler server.ToolHandlerFunc
+	}{
+		{"calculate_total", calculateTotalHandler},
+		{"place_order", placeOrderHandler},
+	} {
+		result := callTool(t, tool.handler, map[string]interface{}{"items": overLimit})
+		if result["error"] != "Too many line items" || result["item_count"] != 6.0 || result["max_line_items"] != 5.0 {
+			t.Errorf("%s with %d items got %v, want Too many line items", tool.name, len(overLimit), result)
+		}
+	}
+}
+
+func TestParseItemsReportsEveryProblem(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &maxLineQuantity, 10)
+
+	items := append(itemsArg("99", 1, "2", 0, "1", 1, "3", 11), "not an object")
+	result := callTool(t, calculateTotalHandler, map[string]interface{}{"items": items})
+	if result["success"] != false || result["error"] != "Invalid items" {
+		t.Fatalf("got %v, want Invalid items", result)
+	}
+
+	want := map[float64]string{
+		0: "Product not found",
+		1: "Quantity must be greater than 0",
+		3: "Quantity cannot exceed 10",
+		4: "Invalid item format",
+	}
+	problems, _ := result["errors"].([]interface{})
+	if result["error_count"] != float64(len(want)) || len(problems) != len(want) {
+		t.Fatalf("got error_count %v and %d errors, want %d: %v", result["error_count"], len(problems), len(want), problems)
+	}
+	for _, p := range problems {
+		problem := p.(map[string]interface{})
+		index, _ := problem["index"].(float64)
+		if problem["error"] != want[index] {
+			t.Errorf("item %v: got error %q, want %q", problem["index"], problem["error"], want[index])
+		}
+		delete(want, index)
+	}
+	if len(want) > 0 {
+		t.Errorf("no error reported for items %v", want)
+	}
+}
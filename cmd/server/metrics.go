@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// latencySamples is the number of recent latencies kept per tool for
+// percentile calculation
+const latencySamples = 1024
+
+// toolStats holds the counters for a single tool
+type toolStats struct {
+	calls  atomic.Int64
+	errors atomic.Int64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+// record adds a latency sample, overwriting the oldest once the buffer is full
+func (t *toolStats) record(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.latencies) < latencySamples {
+		t.latencies = append(t.latencies, d)
+		return
+	}
+	t.latencies[t.next] = d
+	t.next = (t.next + 1) % latencySamples
+}
+
+// percentiles returns the p50, p90 and p99 of the recorded latencies
+func (t *toolStats) percentiles() (p50, p90, p99 time.Duration) {
+	t.mu.Lock()
+	sorted := make([]time.Duration, len(t.latencies))
+	copy(sorted, t.latencies)
+	t.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return 0, 0, 0
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	at := func(p float64) time.Duration {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+	return at(0.50), at(0.90), at(0.99)
+}
+
+// toolMetrics maps a tool name to its *toolStats
+var toolMetrics sync.Map
+
+func statsFor(name string) *toolStats {
+	if stats, ok := toolMetrics.Load(name); ok {
+		return stats.(*toolStats)
+	}
+	stats, _ := toolMetrics.LoadOrStore(name, &toolStats{})
+	return stats.(*toolStats)
+}
+
+// metricsMiddleware records the call count, error count and latency of every
+// tool invocation. The call is recorded in a defer so a panic, which recovery
+// only turns into a result further out, still counts as an error.
+func metricsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+		start := time.Now()
+		panicked := true
+		defer func() {
+			stats := statsFor(req.Params.Name)
+			stats.calls.Add(1)
+			if panicked || err != nil || (result != nil && result.IsError) {
+				stats.errors.Add(1)
+			}
+			stats.record(time.Since(start))
+		}()
+
+		result, err = next(ctx, req)
+		panicked = false
+		return result, err
+	}
+}
+
+// durationMillis converts a duration to fractional milliseconds
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func statsHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var names []string
+	toolMetrics.Range(func(key, _ any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	sort.Strings(names)
+
+	tools := make([]map[string]interface{}, 0, len(names))
+	var totalCalls, totalErrors int64
+	for _, name := range names {
+		stats := statsFor(name)
+		calls, errors := stats.calls.Load(), stats.errors.Load()
+		p50, p90, p99 := stats.percentiles()
+		totalCalls += calls
+		totalErrors += errors
+
+		tools = append(tools, map[string]interface{}{
+			"tool":           name,
+			"calls":          calls,
+			"errors":         errors,
+			"latency_p50_ms": durationMillis(p50),
+			"latency_p90_ms": durationMillis(p90),
+			"latency_p99_ms": durationMillis(p99),
+		})
+	}
+
+	return jsonResult(map[string]interface{}{
+		"success":      true,
+		"tools":        tools,
+		"total_calls":  totalCalls,
+		"total_errors": totalErrors,
+		"sessions":     sessions.count(),
+		"message":      localize(ctx, "tool_stats", totalCalls, len(tools), totalErrors),
+	}), nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode selects how half-cent amounts are rounded
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero (0.125 -> 0.13)
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the nearest even cent (0.125 -> 0.12),
+	// also known as banker's rounding
+	RoundHalfEven
+)
+
+// moneyRounding is the rounding mode applied to every computed price
+var moneyRounding = RoundHalfUp
+
+// currencyDecimals is the number of minor unit digits of each supported
+// ISO 4217 currency: USD has cents, JPY has no minor unit
+var currencyDecimals = map[string]int{
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"TWD": 2,
+	"CNY": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+}
+
+// storeCurrency is the currency of every price in the store
+var storeCurrency = "USD"
+
+// currencySymbols is the symbol written before amounts in messages for each
+// supported currency
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"TWD": "NT$",
+	"CNY": "CN¥",
+	"JPY": "¥",
+	"KRW": "₩",
+	"KWD": "KD ",
+}
+
+// currencySymbolOverride, when set, replaces the store currency's symbol in
+// messages
+var currencySymbolOverride = ""
+
+// currencySymbol returns the symbol written before amounts in messages,
+// falling back to the currency code
+func currencySymbol() string {
+	if currencySymbolOverride != "" {
+		return currencySymbolOverride
+	}
+	if symbol, ok := currencySymbols[storeCurrency]; ok {
+		return symbol
+	}
+	return storeCurrency + " "
+}
+
+// moneyDecimals returns the number of minor unit digits of the store currency
+func moneyDecimals() int {
+	return currencyDecimals[storeCurrency]
+}
+
+// toMinorUnits converts an amount to whole minor units of the store
+// currency, such as cents for USD or yen for JPY
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * math.Pow10(moneyDecimals())))
+}
+
+// fromMinorUnits converts whole minor units back to an amount
+func fromMinorUnits(units int64) float64 {
+	return float64(units) / math.Pow10(moneyDecimals())
+}
+
+// allocateUnits splits total whole units in proportion to weights. Each
+// weight gets the whole units of its exact share, and the units left over go
+// to the largest remainders, so the parts always sum exactly to total.
+// Earlier weights win ties so the split is deterministic.
+func allocateUnits(total int64, weights []int64) []int64 {
+	units := make([]int64, len(weights))
+	var totalWeight int64
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	if totalWeight <= 0 {
+		return units
+	}
+
+	remainders := make([]int64, len(weights))
+	allocated := int64(0)
+	for i, weight := range weights {
+		units[i] = total * weight / totalWeight
+		remainders[i] = total * weight % totalWeight
+		allocated += units[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := 0; allocated < total; i++ {
+		units[order[i%len(order)]]++
+		allocated++
+	}
+	return units
+}
+
+// roundMoney rounds an amount to whole minor units of the store currency
+// using the configured mode
+func roundMoney(amount float64) float64 {
+	scale := math.Pow10(moneyDecimals())
+
+	// Trim binary noise first so 1.005 is treated as an exact half cent
+	units := math.Round(amount*scale*1e6) / 1e6
+
+	switch moneyRounding {
+	case RoundHalfEven:
+		units = math.RoundToEven(units)
+	default:
+		units = math.Round(units)
+	}
+	return units / scale
+}
+
+// money marks a message argument as an amount of money so localize formats it
+// with the thousands and decimal separators of the call's number format.
+// Structured result fields stay plain float64.
+type money float64
+
+// numberFormat is the separators used to write amounts and the side of the
+// amount the currency symbol goes on
+type numberFormat struct {
+	Thousands string
+	Decimal   string
+	// SymbolAfter writes the symbol after the amount, separated by a
+	// no-break space, as in 1.000,00 €, instead of before it as in €1,000.00
+	SymbolAfter bool
+}
+
+// numberFormats are the supported number formats. Message locales use the
+// format of the same name unless numberFormatOverride is set.
+var numberFormats = map[string]numberFormat{
+	localeEN:   {Thousands: ",", Decimal: "."},
+	localeZhTW: {Thousands: ",", Decimal: "."},
+	"de":       {Thousands: ".", Decimal: ",", SymbolAfter: true},
+	"fr":       {Thousands: " ", Decimal: ",", SymbolAfter: true},
+	"plain":    {Thousands: "", Decimal: "."},
+}
+
+// numberFormatOverride, when set, names the number format used for every
+// message regardless of its language
+var numberFormatOverride = ""
+
+// numberFormatFor returns the number format for messages in the locale
+func numberFormatFor(locale string) numberFormat {
+	if format, ok := numberFormats[numberFormatOverride]; ok {
+		return format
+	}
+	if format, ok := numberFormats[locale]; ok {
+		return format
+	}
+	return numberFormats[localeEN]
+}
+
+// formattedMoney is a money argument bound to a number format
+type formattedMoney struct {
+	amount money
+	format numberFormat
+}
+
+// Format implements fmt.Formatter so templates keep using verbs like %.2f.
+// Amounts are always written with the store currency's symbol and minor unit
+// digits, so a JPY total prints as ¥1,000 whatever precision the verb asks
+// for. Templates must not add a symbol of their own.
+func (m formattedMoney) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, m.format.formatMoney(float64(m.amount)))
+}
+
+// formatMoney writes an amount of the store currency with its symbol on the
+// side the format asks for, e.g. -€5.00 or -5,00 €
+func (nf numberFormat) formatMoney(amount float64) string {
+	digits := nf.formatAmount(amount, moneyDecimals())
+	symbol := currencySymbol()
+	if nf.SymbolAfter {
+		return digits + "\u00a0" + strings.TrimSpace(symbol)
+	}
+	if unsigned, negative := strings.CutPrefix(digits, "-"); negative {
+		return "-" + symbol + unsigned
+	}
+	return symbol + digits
+}
+
+// formatAmount writes an amount with the given number of decimals, grouping
+// the integer digits in threes, e.g. 1,000,000.00
+func (nf numberFormat) formatAmount(amount float64, precision int) string {
+	digits := strconv.FormatFloat(math.Abs(amount), 'f', precision, 64)
+	integer, fraction, _ := strings.Cut(digits, ".")
+
+	var sb strings.Builder
+	if amount < 0 && strings.Trim(digits, "0.") != "" {
+		sb.WriteByte('-')
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			sb.WriteString(nf.Thousands)
+		}
+		sb.WriteRune(digit)
+	}
+	if fraction != "" {
+		sb.WriteString(nf.Decimal)
+		sb.WriteString(fraction)
+	}
+	return sb.String()
+}
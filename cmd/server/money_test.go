@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRoundMoney(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		mode   RoundingMode
+		want   float64
+	}{
+		{"33 percent of 1000", 1000 * (33.0 / 100), RoundHalfUp, 330},
+		{"a third of 1000", 1000.0 / 3, RoundHalfUp, 333.33},
+		{"two thirds of 1000", 2000.0 / 3, RoundHalfUp, 666.67},
+		{"float sum", 0.1 + 0.2, RoundHalfUp, 0.3},
+		{"half cent up", 1.005, RoundHalfUp, 1.01},
+		{"half cent up from binary noise", 2.675, RoundHalfUp, 2.68},
+		{"negative half cent", -1.005, RoundHalfUp, -1.01},
+		{"half cent to even down", 0.125, RoundHalfEven, 0.12},
+		{"half cent to even up", 0.135, RoundHalfEven, 0.14},
+		{"below half with even mode", 0.1249, RoundHalfEven, 0.12},
+		{"above half with even mode", 0.1251, RoundHalfEven, 0.13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := moneyRounding
+			moneyRounding = tt.mode
+			defer func() { moneyRounding = prev }()
+
+			if got := roundMoney(tt.amount); got != tt.want {
+				t.Errorf("roundMoney(%v) = %v, want %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDiscountRoundsPrices(t *testing.T) {
+	tests := []struct {
+		total, percentage float64
+		discounted, saved float64
+	}{
+		{1000, 33, 330, 670},
+		{100, 33.333, 33.33, 66.67},
+		{19.99, 15, 3, 16.99},
+	}
+	for _, tt := range tests {
+		result := callTool(t, applyDiscountHandler, map[string]interface{}{
+			"total_price":         tt.total,
+			"discount_percentage": tt.percentage,
+		})
+		if result["discounted_price"] != tt.discounted || result["saved_amount"] != tt.saved {
+			t.Errorf("%v at %v%%: discounted %v saved %v, want %v and %v",
+				tt.total, tt.percentage, result["discounted_price"], result["saved_amount"], tt.discounted, tt.saved)
+		}
+	}
+}
+
+func TestRoundMoneyMinorUnits(t *testing.T) {
+	tests := []struct {
+		currency string
+		amount   float64
+		want     float64
+	}{
+		{"JPY", 1000.0 / 3, 333},
+		{"JPY", 1234.5, 1235},
+		{"JPY", 1234.49, 1234},
+		{"JPY", 1999 * 0.15, 300},
+		{"KRW", 9999.5, 10000},
+		{"USD", 1234.567, 1234.57},
+		{"KWD", 1.0005, 1.001},
+		{"KWD", 10.0 / 3, 3.333},
+	}
+	for _, tt := range tests {
+		setVar(t, &storeCurrency, tt.currency)
+		if got := roundMoney(tt.amount); got != tt.want {
+			t.Errorf("%s: roundMoney(%v) = %v, want %v", tt.currency, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestZeroDecimalCurrencyTools(t *testing.T) {
+	setVar(t, &storeCurrency, "JPY")
+	useCatalog(t, []Product{
+		{ID: "1", Name: "Tea", Price: 333.4, Stock: 100},
+		{ID: "2", Name: "Cup", Price: 1999, Stock: 100},
+	})
+
+	price := callTool(t, getPriceHandler, map[string]interface{}{"product_id": "1"})
+	if price["price"] != 333.0 {
+		t.Errorf("get_price returned %v, want 333", price["price"])
+	}
+
+	total := callTool(t, calculateTotalHandler, map[string]interface{}{"items": itemsArg("1", 3, "2", 1), "tax_rate": 0.08})
+	if total["total_price"] != 2999.0 || total["tax"] != 240.0 || total["gross_total"] != 3239.0 {
+		t.Errorf("calculate_total returned total %v tax %v gross %v, want 2999, 240 and 3239",
+			total["total_price"], total["tax"], total["gross_total"])
+	}
+
+	discount := callTool(t, applyDiscountHandler, map[string]interface{}{"total_price": 1999.0, "discount_percentage": 15.0})
+	if discount["discounted_price"] != 300.0 || discount["saved_amount"] != 1699.0 {
+		t.Errorf("apply_discount returned %v saving %v, want 300 saving 1699", discount["discounted_price"], discount["saved_amount"])
+	}
+	if message, _ := discount["message"].(string); !strings.Contains(message, "¥300") || strings.Contains(message, "¥300.") {
+		t.Errorf("message %q should show ¥300 without decimals", message)
+	}
+}
+
+func TestFormatMoneySymbolPosition(t *testing.T) {
+	tests := []struct {
+		format, currency string
+		amount           float64
+		want             string
+	}{
+		{"en", "USD", 1000, "$1,000.00"},
+		{"en", "USD", -5, "-$5.00"},
+		{"en", "TWD", 1234567.5, "NT$1,234,567.50"},
+		{"de", "EUR", 1000, "1.000,00\u00a0€"},
+		{"de", "EUR", -5, "-5,00\u00a0€"},
+		{"fr", "EUR", 1234567.89, "1\u202f234\u202f567,89\u00a0€"},
+		{"de", "KWD", 1000, "1.000,000\u00a0KD"},
+		{"plain", "USD", 1000, "$1000.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.currency, func(t *testing.T) {
+			setVar(t, &storeCurrency, tt.currency)
+			if got := numberFormats[tt.format].formatMoney(tt.amount); got != tt.want {
+				t.Errorf("formatMoney(%v) = %q, want %q", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizeUsesNumberFormatOverride(t *testing.T) {
+	setVar(t, &storeCurrency, "EUR")
+	setVar(t, &numberFormatOverride, "de")
+	want := "Total price is 1.000,00\u00a0€"
+	if got := localize(context.Background(), "total_price", money(1000)); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// OrderLine is a single product line of a placed order
+type OrderLine struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Price       float64 `json:"price"`
+	Quantity    int     `json:"quantity"`
+	ItemTotal   float64 `json:"item_total"`
+	// Preorder lines ship once the product is released on AvailableFrom
+	Preorder      bool   `json:"preorder,omitempty"`
+	AvailableFrom string `json:"available_from,omitempty"`
+}
+
+// Order statuses
+const (
+	orderPlaced    = "placed"
+	orderCancelled = "cancelled"
+)
+
+// Order is an order that has been placed against the catalog
+type Order struct {
+	ID          string      `json:"order_id"`
+	Items       []OrderLine `json:"items"`
+	TotalPrice  float64     `json:"total_price"`
+	Status      string      `json:"status"`
+	CreatedAt   time.Time   `json:"created_at"`
+	CancelledAt *time.Time  `json:"cancelled_at,omitempty"`
+}
+
+var (
+	errOrderNotFound  = errors.New("order not found")
+	errOrderCancelled = errors.New("order already cancelled")
+)
+
+// orderStore keeps placed orders in memory
+type orderStore struct {
+	mu     sync.Mutex
+	nextID int
+	orders map[string]*Order
+}
+
+var orders = &orderStore{orders: make(map[string]*Order)}
+
+// snapshot returns a copy of the order with the given ID, safe to read while
+// the order is being cancelled
+func (s *orderStore) snapshot(id string) (Order, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return Order{}, false
+	}
+	return *order, true
+}
+
+// add assigns an ID to the order and stores it
+func (s *orderStore) add(order *Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	order.ID = fmt.Sprintf("ORD-%04d", s.nextID)
+	order.Status = orderPlaced
+	s.orders[order.ID] = order
+}
+
+// cancel marks the order cancelled and puts its items back in stock. Both
+// happen under the order lock, so an order is only ever restocked once. It
+// returns a copy of the order as it was before and the stock of each product
+// after; products removed from the catalog since are not restocked.
+func (s *orderStore) cancel(id string, now time.Time) (Order, map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok {
+		return Order{}, nil, errOrderNotFound
+	}
+	if order.Status == orderCancelled {
+		return *order, nil, errOrderCancelled
+	}
+
+	before := *order
+	restocked := catalog.RestoreStock(order.Items)
+	order.Status = orderCancelled
+	order.CancelledAt = &now
+	return before, restocked, nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "items": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "properties": {
+	          "product_id": {"type": "string"},
+	          "quantity": {"type": ["integer", "string"]}
+	        },
+	        "required": ["product_id", "quantity"]
+	      }
+	    },
+	    "dry_run": {"type": "boolean"},
+	    "idempotency_key": {"type": "string"},
+	    "allow_preorder": {"type": "boolean"}
+	  },
+	  "required": ["items"]
+	}
+*/
+func placeOrderHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	items, ok := args["items"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("items is not an array")
+	}
+	if len(items) == 0 {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "Order must contain at least one item",
+		}), nil
+	}
+
+	lines, errResult := parseItems(ctx, items)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	// A dry run validates the order and reports its effect without touching stock
+	dryRun, _ := args["dry_run"].(bool)
+	allowPreorder, _ := args["allow_preorder"].(bool)
+
+	// Without an idempotency key every call places a new order
+	key, _ := args["idempotency_key"].(string)
+	if key == "" || dryRun {
+		return orderResult(submitOrder(ctx, lines, dryRun, allowPreorder))
+	}
+
+	var result map[string]interface{}
+	orderID, duplicate, err := idempotencyKeys.Do(key, func() (string, error) {
+		var order *Order
+		var err error
+		result, order, err = submitOrder(ctx, lines, false, allowPreorder)
+		if order == nil {
+			return "", err
+		}
+		return order.ID, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if duplicate {
+		order, ok := orders.snapshot(orderID)
+		if !ok {
+			return nil, fmt.Errorf("order %s for idempotency key %s not found", orderID, key)
+		}
+		return jsonResult(map[string]interface{}{
+			"success":         true,
+			"tax_inclusive":   pricesIncludeTax,
+			"committed":       true,
+			"duplicate":       true,
+			"idempotency_key": key,
+			"order_id":        order.ID,
+			"status":          order.Status,
+			"items":           order.Items,
+			"item_count":      len(order.Items),
+			"total_price":     order.TotalPrice,
+			"message":         localize(ctx, "order_duplicate", order.ID, money(order.TotalPrice)),
+		}), nil
+	}
+
+	result["duplicate"] = false
+	result["idempotency_key"] = key
+	return orderResult(result, nil, nil)
+}
+
+// orderResult converts the outcome of submitOrder into a tool result
+func orderResult(result map[string]interface{}, _ *Order, err error) (*mcp.CallToolResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	if success, _ := result["success"].(bool); !success {
+		return jsonError(result), nil
+	}
+	return jsonResult(result), nil
+}
+
+// submitOrder checks unreleased products and the minimum order value, reserves
+// stock for the lines and records the order. With dryRun the stock is only
+// checked and no order is recorded. A failed order returns its structured
+// error as the result with a nil order.
+func submitOrder(ctx context.Context, lines []lineItem, dryRun, allowPreorder bool) (map[string]interface{}, *Order, error) {
+	order := &Order{
+		Items:     make([]OrderLine, 0, len(lines)),
+		CreatedAt: time.Now(),
+	}
+	for _, line := range lines {
+		itemTotal := roundMoney(line.Product.Price * float64(line.Quantity))
+		order.TotalPrice = roundMoney(order.TotalPrice + itemTotal)
+		order.Items = append(order.Items, OrderLine{
+			ProductID:   line.Product.ID,
+			ProductName: line.Product.Name,
+			Price:       roundMoney(line.Product.Price),
+			Quantity:    line.Quantity,
+			ItemTotal:   itemTotal,
+		})
+	}
+
+	// Unreleased products are only ordered when the caller accepts a preorder
+	shipsOn, preorderErr := checkPreorders(ctx, lines, allowPreorder, func(i int, date string) {
+		order.Items[i].Preorder = true
+		order.Items[i].AvailableFrom = date
+	})
+	if preorderErr != nil {
+		preorderErr["committed"] = false
+		return preorderErr, nil, nil
+	}
+
+	// Check the minimum purchase before any stock is reserved
+	if order.TotalPrice < minOrderValue {
+		shortfall := roundMoney(minOrderValue - order.TotalPrice)
+		return map[string]interface{}{
+			"success":         false,
+			"error":           "Order below minimum value",
+			"subtotal":        order.TotalPrice,
+			"min_order_value": minOrderValue,
+			"shortfall":       shortfall,
+			"committed":       false,
+			"message":         localize(ctx, "order_below_minimum", money(order.TotalPrice), money(minOrderValue), money(shortfall)),
+		}, nil, nil
+	}
+
+	// Reserve stock for every line at once; nothing is decremented on failure
+	var remaining map[string]int
+	var err error
+	if dryRun {
+		remaining, err = catalog.CheckStock(lines)
+	} else {
+		remaining, err = catalog.DecrementStock(lines)
+	}
+	if err != nil {
+		var stockErr *InsufficientStockError
+		if errors.As(err, &stockErr) {
+			return map[string]interface{}{
+				"success":      false,
+				"error":        "Insufficient stock",
+				"product_id":   stockErr.ProductID,
+				"product_name": stockErr.ProductName,
+				"requested":    stockErr.Requested,
+				"available":    stockErr.Available,
+				"committed":    false,
+				"message":      localize(ctx, "insufficient_stock", stockErr.Available, stockErr.ProductName, stockErr.Requested),
+			}, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	stockImpact := make([]map[string]interface{}, 0, len(order.Items))
+	for _, item := range order.Items {
+		stockImpact = append(stockImpact, map[string]interface{}{
+			"product_id":      item.ProductID,
+			"quantity":        item.Quantity,
+			"stock_remaining": remaining[item.ProductID],
+		})
+	}
+
+	result := map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"currency":      storeCurrency,
+		"committed":     !dryRun,
+		"items":         order.Items,
+		"item_count":    len(order.Items),
+		"total_price":   order.TotalPrice,
+		"stock_impact":  stockImpact,
+	}
+	if shipsOn != "" {
+		result["preorder"] = true
+		result["ships_on"] = shipsOn
+	}
+
+	if dryRun {
+		result["message"] = localize(ctx, "order_preview", money(order.TotalPrice))
+		return result, nil, nil
+	}
+
+	orders.add(order)
+	auditLog.record(ctx, "place_order", order.ID, nil, *order)
+	notifyOrderWebhook(order)
+	result["order_id"] = order.ID
+	result["message"] = localize(ctx, "order_placed", order.ID, money(order.TotalPrice))
+	return result, order, nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "items": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "properties": {
+	          "product_id": {"type": "string"},
+	          "quantity": {"type": ["integer", "string"]}
+	        },
+	        "required": ["product_id", "quantity"]
+	      }
+	    },
+	    "allow_preorder": {"type": "boolean"}
+	  },
+	  "required": ["items"]
+	}
+*/
+func validateOrderHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	items, ok := args["items"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("items is not an array")
+	}
+	if len(items) == 0 {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"valid":   false,
+			"error":   "Order must contain at least one item",
+		}), nil
+	}
+
+	lines, errResult := parseItems(ctx, items)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	// Validation runs every place_order check without reserving stock
+	allowPreorder, _ := args["allow_preorder"].(bool)
+	result, _, err := submitOrder(ctx, lines, true, allowPreorder)
+	if err != nil {
+		return nil, err
+	}
+	valid, _ := result["success"].(bool)
+	result["valid"] = valid
+	if valid {
+		result["message"] = localize(ctx, "order_valid", money(result["total_price"].(float64)))
+		return jsonResult(result), nil
+	}
+	return jsonError(result), nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "order_id": {"type": "string"}
+	  },
+	  "required": ["order_id"]
+	}
+*/
+func cancelOrderHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	orderID, ok := args["order_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing order_id")
+	}
+
+	now := time.Now()
+	before, restocked, err := orders.cancel(orderID, now)
+	switch {
+	case errors.Is(err, errOrderNotFound):
+		return jsonError(map[string]interface{}{
+			"success":  false,
+			"error":    "Order not found",
+			"order_id": orderID,
+			"message":  localize(ctx, "order_not_found", orderID),
+		}), nil
+	case errors.Is(err, errOrderCancelled):
+		return jsonError(map[string]interface{}{
+			"success":      false,
+			"error":        "Order already cancelled",
+			"order_id":     orderID,
+			"cancelled_at": before.CancelledAt.UTC().Format(time.RFC3339),
+			"message":      localize(ctx, "order_already_cancelled", orderID),
+		}), nil
+	case err != nil:
+		return nil, err
+	}
+
+	stockImpact := make([]map[string]interface{}, 0, len(before.Items))
+	for _, item := range before.Items {
+		impact := map[string]interface{}{
+			"product_id": item.ProductID,
+			"quantity":   item.Quantity,
+		}
+		if stock, ok := restocked[item.ProductID]; ok {
+			impact["restocked"] = true
+			impact["stock_remaining"] = stock
+		} else {
+			impact["restocked"] = false
+		}
+		stockImpact = append(stockImpact, impact)
+	}
+
+	auditLog.record(ctx, "cancel_order", orderID, before.Status, orderCancelled)
+	return jsonResult(map[string]interface{}{
+		"success":         true,
+		"tax_inclusive":   pricesIncludeTax,
+		"order_id":        orderID,
+		"status":          orderCancelled,
+		"items":           before.Items,
+		"refunded_amount": before.TotalPrice,
+		"cancelled_at":    now.UTC().Format(time.RFC3339),
+		"stock_impact":    stockImpact,
+		"message":         localize(ctx, "order_cancelled", orderID, money(before.TotalPrice)),
+	}), nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestPlaceOrderLastUnitConcurrently(t *testing.T) {
+	useCatalog(t, []Product{{ID: "1", Name: "Laptop", Price: 1000, Stock: 1}})
+
+	const buyers = 20
+	var wg sync.WaitGroup
+	responses := make([]*mcp.CallToolResult, buyers)
+	errs := make([]error, buyers)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var req mcp.CallToolRequest
+			req.Params.Arguments = map[string]interface{}{
+				"items": []interface{}{map[string]interface{}{"product_id": "1", "quantity": float64(1)}},
+			}
+			responses[i], errs[i] = placeOrderHandler(context.Background(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	placed := 0
+	for i, res := range responses {
+		if errs[i] != nil {
+			t.Fatalf("place_order returned an error: %v", errs[i])
+		}
+		result := decodeResult(t, res)
+		if result["success"] == true {
+			placed++
+		} else if result["error"] != "Insufficient stock" {
+			t.Errorf("failed order has error %v, want Insufficient stock", result["error"])
+		}
+	}
+	if placed != 1 {
+		t.Errorf("%d orders placed for the last unit, want 1", placed)
+	}
+	if product, _ := catalog.Get("1"); product.Stock != 0 {
+		t.Errorf("stock is %d after the orders, want 0", product.Stock)
+	}
+}
+
+func TestPlaceOrderInsufficientStockChangesNothing(t *testing.T) {
+	useCatalog(t, []Product{
+		{ID: "1", Name: "Laptop", Price: 1000, Stock: 5},
+		{ID: "2", Name: "Smartphone", Price: 500, Stock: 1},
+	})
+
+	result := callTool(t, placeOrderHandler, map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"product_id": "1", "quantity": float64(2)},
+			map[string]interface{}{"product_id": "2", "quantity": float64(3)},
+		},
+	})
+	if result["success"] != false || result["product_id"] != "2" {
+		t.Fatalf("got %v, want Insufficient stock for product 2", result)
+	}
+	if product, _ := catalog.Get("1"); product.Stock != 5 {
+		t.Errorf("laptop stock is %d, want 5 untouched", product.Stock)
+	}
+}
+
+func TestMinimumOrderValue(t *testing.T) {
+	useCatalog(t, []Product{
+		{ID: "1", Name: "Cable", Price: 9.99, Stock: 100},
+		{ID: "2", Name: "Adapter", Price: 0.01, Stock: 100},
+	})
+	setVar(t, &minOrderValue, 20.0)
+
+	tests := []struct {
+		name      string
+		items     []interface{}
+		accepted  bool
+		shortfall float64
+	}{
+		{"at the minimum", itemsArg("1", 2, "2", 2), true, 0},
+		{"one cent below", itemsArg("1", 2, "2", 1), false, 0.01},
+		{"well below", itemsArg("1", 1), false, 10.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, tool := range []struct {
+				name    string
+				handler server.ToolHandlerFunc
+			}{{"validate_order", validateOrderHandler}, {"place_order", placeOrderHandler}} {
+				result := callTool(t, tool.handler, map[string]interface{}{"items": tt.items})
+				if tt.accepted {
+					if result["success"] != true {
+						t.Errorf("%s got %v, want the order accepted", tool.name, result)
+					}
+					continue
+				}
+				if result["error"] != "Order below minimum value" || result["shortfall"] != tt.shortfall || result["min_order_value"] != 20.0 {
+					t.Errorf("%s got %v, want Order below minimum value short by %v", tool.name, result, tt.shortfall)
+				}
+			}
+		})
+	}
+
+	// Only the order at the minimum was placed
+	if product, _ := catalog.Get("1"); product.Stock != 98 {
+		t.Errorf("cable stock is %d, want 98", product.Stock)
+	}
+}
+
+func TestNoMinimumOrderValueByDefault(t *testing.T) {
+	useCatalog(t, []Product{{ID: "1", Name: "Adapter", Price: 0.01, Stock: 1}})
+
+	if result := callTool(t, placeOrderHandler, map[string]interface{}{"items": itemsArg("1", 1)}); result["success"] != true {
+		t.Errorf("got %v, want a one cent order accepted without a minimum", result)
+	}
+}
+
+func TestPlaceOrderPreorder(t *testing.T) {
+	useCatalog(t, defaultProducts)
+	watch, _ := catalog.Get("4")
+
+	for _, tool := range []struct {
+		name    string
+		handler server.ToolHandlerFunc
+	}{{"validate_order", validateOrderHandler}, {"place_order", placeOrderHandler}} {
+		result := callTool(t, tool.handler, map[string]interface{}{"items": itemsArg("4", 2)})
+		if result["error"] != "Preorder not allowed" || result["available_from"] != watch.releaseDate() || result["committed"] != false {
+			t.Errorf("%s got %v, want Preorder not allowed until %s", tool.name, result, watch.releaseDate())
+		}
+	}
+	if product, _ := catalog.Get("4"); product.Stock != watch.Stock {
+		t.Fatalf("stock is %d after the rejected order, want %d untouched", product.Stock, watch.Stock)
+	}
+
+	result := callTool(t, placeOrderHandler, map[string]interface{}{"items": itemsArg("4", 2), "allow_preorder": true})
+	if result["success"] != true || result["preorder"] != true || result["ships_on"] != watch.releaseDate() {
+		t.Fatalf("got %v, want a preorder shipping on %s", result, watch.releaseDate())
+	}
+	order, _ := orders.snapshot(result["order_id"].(string))
+	if line := order.Items[0]; !line.Preorder || line.AvailableFrom != watch.releaseDate() {
+		t.Errorf("order line is %+v, want it marked as a preorder", line)
+	}
+	if product, _ := catalog.Get("4"); product.Stock != watch.Stock-2 {
+		t.Errorf("stock is %d after the preorder, want %d", product.Stock, watch.Stock-2)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// pingHandler answers with the server time so clients and smoke tests can
+// confirm that a tool call makes the full round trip
+func pingHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return jsonResult(map[string]interface{}{
+		"success":     true,
+		"pong":        true,
+		"server_time": now,
+		"message":     localize(ctx, "pong", now),
+	}), nil
+}
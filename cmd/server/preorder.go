@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// releaseDateLayout is how release dates appear in results and messages
+const releaseDateLayout = "2006-01-02"
+
+// available reports whether the product has been released at the given time
+func (p Product) available(now time.Time) bool {
+	return !p.AvailableFrom.After(now)
+}
+
+// releaseDate returns the product's release date, e.g. 2024-07-01
+func (p Product) releaseDate() string {
+	return p.AvailableFrom.UTC().Format(releaseDateLayout)
+}
+
+// parseReleaseDate reads a release date given as RFC 3339 or as a plain date,
+// which is taken as midnight UTC
+func parseReleaseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(releaseDateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("available_from must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	return t, nil
+}
+
+// checkPreorders calls mark for each line holding an unreleased product and
+// returns the latest release date among them. Unless allowPreorder is set, the
+// first unreleased product is returned as a structured error instead.
+func checkPreorders(ctx context.Context, lines []lineItem, allowPreorder bool, mark func(i int, date string)) (string, map[string]interface{}) {
+	now := time.Now()
+	shipsOn := ""
+	for i, line := range lines {
+		if line.Product.available(now) {
+			continue
+		}
+
+		date := line.Product.releaseDate()
+		if !allowPreorder {
+			return "", map[string]interface{}{
+				"success":        false,
+				"error":          "Preorder not allowed",
+				"product_id":     line.Product.ID,
+				"product_name":   line.Product.Name,
+				"preorder":       true,
+				"available_from": date,
+				"message":        localize(ctx, "preorder_not_allowed", line.Product.Name, date),
+			}
+		}
+
+		mark(i, date)
+		if date > shipsOn {
+			shipsOn = date
+		}
+	}
+	return shipsOn, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// PriceChange is a single entry in a product's price history
+type PriceChange struct {
+	Timestamp time.Time `json:"timestamp"`
+	OldPrice  float64   `json:"old_price"`
+	NewPrice  float64   `json:"new_price"`
+}
+
+// priceHistoryStore keeps the price changes of every product in memory
+type priceHistoryStore struct {
+	mu      sync.RWMutex
+	changes map[string][]PriceChange
+}
+
+var priceHistory = &priceHistoryStore{changes: make(map[string][]PriceChange)}
+
+// record appends a price change for the product
+func (h *priceHistoryStore) record(productID string, oldPrice, newPrice float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.changes[productID] = append(h.changes[productID], PriceChange{
+		Timestamp: time.Now(),
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+	})
+}
+
+// get returns a copy of the product's price changes, oldest first
+func (h *priceHistoryStore) get(productID string) []PriceChange {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	history := make([]PriceChange, len(h.changes[productID]))
+	copy(history, h.changes[productID])
+	return history
+}
+
+// changesSince counts the product's price changes after t
+func (h *priceHistoryStore) changesSince(productID string, t time.Time) int {
+	count := 0
+	for _, change := range h.get(productID) {
+		if change.Timestamp.After(t) {
+			count++
+		}
+	}
+	return count
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "product_id": {"type": "string"}
+	  },
+	  "required": ["product_id"]
+	}
+*/
+func getPriceHistoryHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	productID, ok := args["product_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("product_id is not a string")
+	}
+
+	product, ok := catalog.Get(productID)
+	if !ok {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "Product not found",
+			"product_id": productID,
+		}), nil
+	}
+
+	history := priceHistory.get(productID)
+	message := localize(ctx, "price_history_changed", product.Name, len(history), money(product.Price))
+	if len(history) == 0 {
+		message = localize(ctx, "price_history_unchanged", product.Name, money(product.Price))
+	}
+
+	return jsonResult(map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"product_id":    product.ID,
+		"product_name":  product.Name,
+		"current_price": roundMoney(product.Price),
+		"history":       history,
+		"change_count":  len(history),
+		"message":       message,
+	}), nil
+}
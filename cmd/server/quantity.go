@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// chineseDigits maps Chinese digit characters to their values
+var chineseDigits = map[rune]int{
+	'零': 0, '〇': 0,
+	'一': 1, '壹': 1,
+	'二': 2, '兩': 2, '两': 2, '貳': 2,
+	'三': 3, '參': 3,
+	'四': 4, '肆': 4,
+	'五': 5, '伍': 5,
+	'六': 6, '陸': 6,
+	'七': 7, '柒': 7,
+	'八': 8, '捌': 8,
+	'九': 9, '玖': 9,
+}
+
+// chineseUnits maps Chinese multiplier characters below 萬 to their values
+var chineseUnits = map[rune]int{
+	'十': 10, '拾': 10,
+	'百': 100, '佰': 100,
+	'千': 1000, '仟': 1000,
+}
+
+// parseQuantity converts an Arabic, English or Chinese numeral string such as
+// "30", "twenty-five", "三十" or "一百零五", or a counted unit such as "a dozen"
+// or 半打, into an integer of at least 1
+func parseQuantity(text string) (int, error) {
+	s := strings.TrimSpace(text)
+	if s == "" {
+		return 0, fmt.Errorf("empty quantity")
+	}
+
+	// Normalize full-width digits so "３０" parses like "30"
+	s = strings.Map(func(r rune) rune {
+		if r >= '０' && r <= '９' {
+			return r - '０' + '0'
+		}
+		return r
+	}, s)
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		var ok bool
+		if n, ok = parseQuantityWords(s); !ok {
+			if n, err = parseChineseNumeral(s); err != nil {
+				return 0, fmt.Errorf("unrecognized quantity %q: use a number, a Chinese numeral or a unit such as dozen, pair or 打", text)
+			}
+		}
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("quantity %q must be at least 1", text)
+	}
+	return n, nil
+}
+
+// quantityUnits maps counting words to the number of items they stand for
+var quantityUnits = map[string]int{
+	"dozen": 12, "dozens": 12,
+	"pair": 2, "pairs": 2,
+	"couple": 2,
+	"打":      12,
+	"對":      2, "对": 2, "雙": 2, "双": 2,
+}
+
+// englishNumbers maps English number words below twenty to their values
+var englishNumbers = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+	"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+}
+
+// englishTens maps English multiples of ten to their values
+var englishTens = map[string]int{
+	"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+	"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+// parseEnglishNumber parses an English number below one hundred given as
+// words, e.g. ["seven"], ["twenty"] or ["twenty", "five"] from "twenty-five"
+func parseEnglishNumber(words []string) (int, bool) {
+	switch len(words) {
+	case 1:
+		if n, ok := englishNumbers[words[0]]; ok {
+			return n, true
+		}
+		n, ok := englishTens[words[0]]
+		return n, ok
+	case 2:
+		tens, ok := englishTens[words[0]]
+		ones, ok2 := englishNumbers[words[1]]
+		if ok && ok2 && ones < 10 {
+			return tens + ones, true
+		}
+	}
+	return 0, false
+}
+
+// parseQuantityWords recognizes English numbers such as "twenty-five" and
+// counted units such as "a dozen", "half a dozen", "3 pairs", 一打, 半打 and 兩雙
+func parseQuantityWords(s string) (int, bool) {
+	lower := strings.ToLower(strings.TrimSpace(s))
+
+	// English: "[count] unit", where the count may be a number, "a", "half" or
+	// "half a". A leading minus would be lost as a hyphen, so it never matches.
+	if strings.HasPrefix(lower, "-") {
+		return 0, false
+	}
+	words := strings.FieldsFunc(lower, func(r rune) bool { return unicode.IsSpace(r) || r == '-' })
+	if len(words) > 0 {
+		if n, ok := parseEnglishNumber(words); ok {
+			return n, true
+		}
+		if unit, ok := quantityUnits[words[len(words)-1]]; ok {
+			count := words[:len(words)-1]
+			switch {
+			case len(count) == 0:
+				return unit, true
+			case count[0] == "half" && (len(count) == 1 || (len(count) == 2 && (count[1] == "a" || count[1] == "an"))):
+				if unit%2 == 0 {
+					return unit / 2, true
+				}
+			case len(count) == 1 && (count[0] == "a" || count[0] == "an"):
+				return unit, true
+			default:
+				if n, ok := parseEnglishNumber(count); ok {
+					return n * unit, true
+				}
+				if len(count) == 1 {
+					if n, err := strconv.Atoi(count[0]); err == nil {
+						return n * unit, true
+					}
+				}
+			}
+			return 0, false
+		}
+	}
+
+	// Chinese: a numeral followed by a unit character, e.g. 一打, 兩雙, 半打
+	runes := []rune(lower)
+	if len(runes) < 2 {
+		return 0, false
+	}
+	unit, ok := quantityUnits[string(runes[len(runes)-1])]
+	if !ok {
+		return 0, false
+	}
+	count := string(runes[:len(runes)-1])
+	if count == "半" {
+		return unit / 2, true
+	}
+	if n, err := strconv.Atoi(count); err == nil {
+		return n * unit, true
+	}
+	if n, err := parseChineseNumeral(count); err == nil {
+		return n * unit, true
+	}
+	return 0, false
+}
+
+// parseChineseNumeral parses Chinese numerals up to the 億 range, including
+// compounds like 十五, 二十, 三百零五 and 一萬二千. Every digit but 零 needs a
+// unit after it, so digit strings such as 三五 are rejected.
+func parseChineseNumeral(s string) (int, error) {
+	total, section, digit := 0, 0, 0
+	seenDigit := false
+
+	for _, r := range s {
+		if d, ok := chineseDigits[r]; ok {
+			if seenDigit && digit != 0 {
+				return 0, fmt.Errorf("digit %q follows another digit without a unit in %q", r, s)
+			}
+			digit = d
+			seenDigit = true
+			continue
+		}
+		if unit, ok := chineseUnits[r]; ok {
+			// A bare 十 at the start of a section means 一十
+			if digit == 0 && unit == 10 && !seenDigit {
+				digit = 1
+			}
+			section += digit * unit
+			digit, seenDigit = 0, false
+			continue
+		}
+		switch r {
+		case '萬', '万':
+			total += (section + digit) * 10000
+		case '億', '亿':
+			total = (total + section + digit) * 100000000
+		default:
+			if unicode.IsSpace(r) {
+				continue
+			}
+			return 0, fmt.Errorf("unrecognized numeral %q in %q", r, s)
+		}
+		section, digit, seenDigit = 0, 0, false
+	}
+
+	return total + section + digit, nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "text": {"type": "string"}
+	  },
+	  "required": ["text"]
+	}
+*/
+func parseQuantityHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	text, ok := args["text"].(string)
+	if !ok {
+		return nil, fmt.Errorf("text is not a string")
+	}
+
+	quantity, err := parseQuantity(text)
+	if err != nil {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "Unrecognized quantity",
+			"input":   text,
+			"message": err.Error(),
+		}), nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"success":  true,
+		"input":    text,
+		"quantity": quantity,
+		"message":  localize(ctx, "quantity_parsed", text, quantity),
+	}), nil
+}
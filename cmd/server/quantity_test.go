@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+		err  bool
+	}{
+		{"30", 30, false},
+		{"３０", 30, false},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"三十", 30, false},
+		{"十五", 15, false},
+		{"一百零五", 105, false},
+		{"一萬二千", 12000, false},
+		{"零", 0, true},
+		{"三五", 0, true},
+		{"一二三", 0, true},
+		{"seven", 7, false},
+		{"Twenty", 20, false},
+		{"twenty-five", 25, false},
+		{"twenty five", 25, false},
+		{"twenty-twelve", 0, true},
+		{"a dozen", 12, false},
+		{"half a dozen", 6, false},
+		{"3 pairs", 6, false},
+		{"twenty pairs", 40, false},
+		{"-5 pairs", 0, true},
+		{"0 dozen", 0, true},
+		{"3 of dozen", 0, true},
+		{"一打", 12, false},
+		{"半打", 6, false},
+		{"兩雙", 4, false},
+		{"some", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseQuantity(tt.text)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("parseQuantity(%q) = %d, %v, want %d (error %v)", tt.text, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestParseQuantityMessage(t *testing.T) {
+	setVar(t, &defaultLocale, localeZhTW)
+
+	result := callTool(t, parseQuantityHandler, map[string]interface{}{"text": "三十"})
+	if result["quantity"] != 30.0 || result["message"] != "三十 是 30" {
+		t.Errorf("got %v, want 30 with a zh-TW message", result)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultRateLimitKey names the limit that applies to tools without their own
+const defaultRateLimitKey = "*"
+
+// rateLimit allows Calls calls per Per, refilled continuously, with bursts
+// of up to Calls calls
+type rateLimit struct {
+	Calls int
+	Per   time.Duration
+}
+
+func (l rateLimit) String() string {
+	return fmt.Sprintf("%d/%v", l.Calls, l.Per)
+}
+
+// perSecond returns the refill rate of the limit's bucket
+func (l rateLimit) perSecond() float64 {
+	return float64(l.Calls) / l.Per.Seconds()
+}
+
+// parseRateLimits parses a list such as "*=20/1s,place_order=5/1m" into
+// limits by tool name, where "*" is the default for every other tool. The
+// duration may omit a leading 1, as in 10/s.
+func parseRateLimits(spec string) (map[string]rateLimit, error) {
+	limits := make(map[string]rateLimit)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not tool=calls/duration", entry)
+		}
+		calls, per, ok := strings.Cut(strings.TrimSpace(value), "/")
+		if !ok {
+			return nil, fmt.Errorf("%q is not tool=calls/duration", entry)
+		}
+		n, err := strconv.Atoi(calls)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%q: calls must be a positive integer", entry)
+		}
+		if per != "" && !unicode.IsDigit(rune(per[0])) {
+			per = "1" + per
+		}
+		d, err := time.ParseDuration(per)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%q: invalid duration %q", entry, per)
+		}
+		limits[strings.TrimSpace(name)] = rateLimit{Calls: n, Per: d}
+	}
+	return limits, nil
+}
+
+var (
+	// toolRateLimits holds the limit of each tool, and the default under
+	// defaultRateLimitKey; empty disables rate limiting
+	toolRateLimits = map[string]rateLimit{}
+	// rateLimitBySession gives every MCP client session its own buckets
+	// instead of sharing one per tool
+	rateLimitBySession = false
+)
+
+// tokenBucket is the remaining allowance of one tool, or one tool in one
+// session
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter tracks the token buckets of every rate limited key
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+var limiter = &rateLimiter{buckets: make(map[string]*tokenBucket)}
+
+// allow takes a token from the key's bucket. When none is left it returns
+// false and how long until the next token is available.
+func (r *rateLimiter) allow(key string, limit rateLimit, now time.Time) (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket, ok := r.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Calls), last: now}
+		r.buckets[key] = bucket
+	}
+
+	rate := limit.perSecond()
+	bucket.tokens = math.Min(float64(limit.Calls), bucket.tokens+now.Sub(bucket.last).Seconds()*rate)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	return false, wait
+}
+
+// limitFor returns the limit of a tool, falling back to the default
+func limitFor(tool string) (rateLimit, bool) {
+	if limit, ok := toolRateLimits[tool]; ok {
+		return limit, true
+	}
+	limit, ok := toolRateLimits[defaultRateLimitKey]
+	return limit, ok
+}
+
+// rateLimitMiddleware rejects calls beyond the tool's rate limit with an
+// error telling the caller when to retry
+func rateLimitMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		limit, ok := limitFor(req.Params.Name)
+		if !ok {
+			return next(ctx, req)
+		}
+
+		key := req.Params.Name
+		if rateLimitBySession {
+			if session := server.ClientSessionFromContext(ctx); session != nil {
+				key += "|" + session.SessionID()
+			}
+		}
+
+		allowed, wait := limiter.allow(key, limit, time.Now())
+		if allowed {
+			return next(ctx, req)
+		}
+
+		retryAfter := math.Ceil(wait.Seconds()*10) / 10
+		return jsonError(map[string]interface{}{
+			"success":             false,
+			"error":               "Rate limited",
+			"tool":                req.Params.Name,
+			"limit":               limit.String(),
+			"retry_after_seconds": retryAfter,
+			"message":             localize(ctx, "rate_limited", req.Params.Name, retryAfter),
+		}), nil
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// receiptFormats are the layouts get_receipt can write
+var receiptFormats = []string{"text", "markdown"}
+
+// receiptTimeLayout is how times are written on receipts
+const receiptTimeLayout = "2006-01-02 15:04 MST"
+
+// formatReceipt writes a printable receipt for the order in the call's
+// locale. Orders are placed at catalog prices with no discounts or shipping,
+// so the receipt lists the lines, the subtotal, the tax at the store's rate
+// and the total. Amounts use the same formatting as messages.
+func formatReceipt(ctx context.Context, order Order, markdown bool) string {
+	_, tax, gross := taxBreakdown(order.TotalPrice, taxRate)
+	ratePercent := strconv.FormatFloat(taxRate*100, 'f', -1, 64)
+
+	var sb strings.Builder
+	line := func(text string) {
+		sb.WriteString(text)
+		sb.WriteByte('\n')
+	}
+
+	title := localize(ctx, "receipt_title", order.ID)
+	if markdown {
+		line("### " + title)
+	} else {
+		line(title)
+		line(strings.Repeat("=", len([]rune(title))))
+	}
+	line(localize(ctx, "receipt_placed", order.CreatedAt.UTC().Format(receiptTimeLayout)))
+	if order.Status == orderCancelled && order.CancelledAt != nil {
+		line(localize(ctx, "receipt_cancelled", order.CancelledAt.UTC().Format(receiptTimeLayout), money(order.TotalPrice)))
+	}
+	line("")
+
+	bullet := ""
+	if markdown {
+		bullet = "- "
+	}
+	for _, item := range order.Items {
+		line(bullet + localize(ctx, "receipt_line", item.Quantity, item.ProductName, money(item.Price), money(item.ItemTotal)))
+	}
+	line("")
+
+	line(bullet + localize(ctx, "receipt_subtotal", money(order.TotalPrice)))
+	taxKey := "receipt_tax"
+	if pricesIncludeTax {
+		taxKey = "receipt_tax_included"
+	}
+	line(bullet + localize(ctx, taxKey, ratePercent, money(tax)))
+	total := localize(ctx, "receipt_total", money(gross))
+	if markdown {
+		total = "**" + total + "**"
+	}
+	line(bullet + total)
+	return sb.String()
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "order_id": {"type": "string"},
+	    "format": {"type": "string", "enum": ["text", "markdown"]}
+	  },
+	  "required": ["order_id"]
+	}
+*/
+func getReceiptHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	orderID, ok := args["order_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing order_id")
+	}
+	format := "text"
+	if v, ok := args["format"].(string); ok && v != "" {
+		format = v
+	}
+
+	order, ok := orders.snapshot(orderID)
+	if !ok {
+		return jsonError(map[string]interface{}{
+			"success":  false,
+			"error":    "Order not found",
+			"order_id": orderID,
+			"message":  localize(ctx, "order_not_found", orderID),
+		}), nil
+	}
+
+	_, _, gross := taxBreakdown(order.TotalPrice, taxRate)
+	return jsonResult(map[string]interface{}{
+		"success":      true,
+		"order_id":     order.ID,
+		"status":       order.Status,
+		"format":       format,
+		"receipt":      formatReceipt(ctx, order, format == "markdown"),
+		"generated_at": time.Now().UTC().Format(time.RFC3339),
+		"message":      localize(ctx, "receipt_ready", order.ID, money(gross)),
+	}), nil
+}
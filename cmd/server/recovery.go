@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// recoveryMiddleware turns a panic in a tool handler, or in any other
+// middleware, into an internal error result, so one bad call does not take
+// down the server and every connected client. The stack trace goes to the
+// log; the caller only learns that the call failed.
+func recoveryMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("trace=%s tool=%s panic: %v\n%s", traceIDFromRequest(req), req.Params.Name, r, debug.Stack())
+				// The locale middleware runs inside this one, so look the
+				// locale up again for the message
+				ctx = context.WithValue(ctx, localeKey{}, requestLocale(req))
+				result = jsonError(map[string]interface{}{
+					"success": false,
+					"error":   "Internal error",
+					"tool":    req.Params.Name,
+					"message": localize(ctx, "internal_error", req.Params.Name),
+				})
+				err = nil
+			}
+		}()
+		return next(ctx, req)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callServer sends a tools/call request through the server's full handler
+// chain and returns the tool result
+func callServer(t *testing.T, s *server.MCPServer, name string, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+	request, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	})
+	response, _ := json.Marshal(s.HandleMessage(context.Background(), request))
+
+	var decoded struct {
+		Result json.RawMessage `json:"result"`
+		Error  interface{}     `json:"error"`
+	}
+	if err := json.Unmarshal(response, &decoded); err != nil || decoded.Error != nil {
+		t.Fatalf("%s: got %s, want a tool result", name, response)
+	}
+	result, err := mcp.ParseCallToolResult(&decoded.Result)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return result
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	toolMetrics.Clear()
+
+	// panicOnTool is a middleware that panics for one tool, to check that
+	// recovery also covers the middleware inside it
+	panicOnTool := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if req.Params.Name == "middleware_panic" {
+				panic("middleware failed")
+			}
+			return next(ctx, req)
+		}
+	}
+	s := server.NewMCPServer("test", "0",
+		server.WithToolHandlerMiddleware(recoveryMiddleware),
+		server.WithToolHandlerMiddleware(metricsMiddleware),
+		server.WithToolHandlerMiddleware(tracingMiddleware),
+		server.WithToolHandlerMiddleware(localeMiddleware),
+		server.WithToolHandlerMiddleware(panicOnTool),
+	)
+	s.AddTool(mcp.NewTool("handler_panic"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var products map[string]Product
+		products["1"] = Product{}
+		return nil, nil
+	})
+	s.AddTool(mcp.NewTool("middleware_panic"), pingHandler)
+	s.AddTool(mcp.NewTool("ping"), pingHandler)
+
+	for _, name := range []string{"handler_panic", "middleware_panic"} {
+		res := callServer(t, s, name, nil)
+		result := decodeResult(t, res)
+		if !res.IsError || result["error"] != "Internal error" || result["tool"] != name {
+			t.Errorf("%s got %v, want Internal error", name, result)
+		}
+	}
+
+	// The message follows the call's locale even though the panic happened
+	// outside the locale middleware
+	result := decodeResult(t, callServer(t, s, "middleware_panic", map[string]interface{}{"locale": "zh-TW"}))
+	if want := fmt.Sprintf(messageCatalog[localeZhTW]["internal_error"], "middleware_panic"); result["message"] != want {
+		t.Errorf("got message %q, want %q", result["message"], want)
+	}
+
+	// The server keeps serving after the panics
+	if res := callServer(t, s, "ping", nil); res.IsError {
+		t.Errorf("ping after panics got %v", decodeResult(t, res))
+	}
+
+	// stats, which runs inside recovery, still counts the panicked calls
+	for name, want := range map[string]int64{"handler_panic": 1, "middleware_panic": 2, "ping": 0} {
+		if errors := statsFor(name).errors.Load(); errors != want {
+			t.Errorf("%s has %d errors in stats, want %d", name, errors, want)
+		}
+	}
+}
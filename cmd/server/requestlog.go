@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Request log settings. requestLogPath empty disables the request log.
+var (
+	requestLogPath = ""
+	// requestLogMaxSize is the size in bytes at which the log is rotated
+	requestLogMaxSize int64 = 10 << 20
+	// requestLogBackups is how many rotated files are kept, as path.1 (the
+	// newest) up to path.N
+	requestLogBackups = 3
+	// requestLogFields, when set, lists the only field names whose values are
+	// written; the values of all other fields are redacted
+	requestLogFields map[string]bool
+)
+
+// redactedValue replaces values left out of requestLogFields
+const redactedValue = "[redacted]"
+
+// rotatingFile is an append-only file that is renamed to path.1 once it
+// reaches maxSize, shifting older backups up and dropping the oldest
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+// openRotatingFile opens or creates the file at path for appending
+func openRotatingFile(path string, maxSize int64, backups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when p would take the file past maxSize.
+// A single write larger than maxSize still goes into one file.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file, shifts the backups and starts a new file.
+// Callers must hold mu.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if r.backups > 0 {
+		for i := r.backups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		}
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// requestLogEntry is one line of the request log
+type requestLogEntry struct {
+	Time      time.Time   `json:"time"`
+	SessionID string      `json:"session_id,omitempty"`
+	ID        any         `json:"id,omitempty"`
+	Method    string      `json:"method"`
+	Direction string      `json:"direction"`
+	Request   interface{} `json:"request,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// requestLogger writes requests and responses as JSON lines
+type requestLogger struct {
+	out *rotatingFile
+}
+
+func (l *requestLogger) write(ctx context.Context, entry requestLogEntry) {
+	entry.Time = time.Now().UTC()
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		entry.SessionID = session.SessionID()
+	}
+	entry.Request = redactFields(entry.Request)
+	entry.Result = redactFields(entry.Result)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Request log entry for %s not written: %v", entry.Method, err)
+		return
+	}
+	if _, err := l.out.Write(append(line, '\n')); err != nil {
+		log.Printf("Request log write failed: %v", err)
+	}
+}
+
+// redactFields returns v as plain JSON data with the values of fields not in
+// requestLogFields redacted. Objects and arrays are walked so the shape of
+// the message stays visible. Without an allowlist v is returned unchanged.
+func redactFields(v interface{}) interface{} {
+	if v == nil || requestLogFields == nil {
+		return v
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return redactedValue
+	}
+	var data interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return redactedValue
+	}
+	return redactValue("", data)
+}
+
+func redactValue(field string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			v[name] = redactValue(name, value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactValue(field, value)
+		}
+		return v
+	}
+	if requestLogFields[field] {
+		return v
+	}
+	return redactedValue
+}
+
+// requestLogHooks returns the hooks that write every request, response and
+// error to the request log, or nil when the log is disabled or cannot be
+// opened. Hooks see requests on every transport, so stdio and HTTP are
+// logged alike without touching the protocol stream.
+func requestLogHooks() *server.Hooks {
+	if requestLogPath == "" {
+		return nil
+	}
+	out, err := openRotatingFile(requestLogPath, requestLogMaxSize, requestLogBackups)
+	if err != nil {
+		log.Printf("Request log %s not opened, requests are not logged: %v", requestLogPath, err)
+		return nil
+	}
+	logger := &requestLogger{out: out}
+
+	hooks := &server.Hooks{}
+	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
+		logger.write(ctx, requestLogEntry{ID: id, Method: string(method), Direction: "request", Request: message})
+	})
+	hooks.AddOnSuccess(func(ctx context.Context, id any, method mcp.MCPMethod, message any, result any) {
+		logger.write(ctx, requestLogEntry{ID: id, Method: string(method), Direction: "response", Result: result})
+	})
+	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
+		logger.write(ctx, requestLogEntry{ID: id, Method: string(method), Direction: "error", Error: err.Error()})
+	})
+	log.Printf("Logging requests to %s", requestLogPath)
+	return hooks
+}
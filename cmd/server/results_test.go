@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TestEmptyListsEncodeAsArrays checks that list fields with nothing in them
+// come out as [] rather than null, which clients that type-check arrays reject
+func TestEmptyListsEncodeAsArrays(t *testing.T) {
+	// Product 9 has no entry in the alias table
+	useCatalog(t, append(testProducts[:len(testProducts):len(testProducts)],
+		Product{ID: "9", Name: "Headphones", Price: 80, Stock: 5}))
+
+	tests := []struct {
+		name    string
+		handler server.ToolHandlerFunc
+		args    map[string]interface{}
+		field   string
+	}{
+		{"calculate_total", calculateTotalHandler, map[string]interface{}{"items": []interface{}{}}, "items"},
+		{"list_products", listProductsHandler, map[string]interface{}{"offset": 100.0}, "products"},
+		{"resolve_alias", resolveAliasHandler, map[string]interface{}{"alias": "Headphones"}, "aliases"},
+		{"wishlist_list", wishlistListHandler, map[string]interface{}{"session_id": "empty-lists-test"}, "items"},
+		{"get_price_history", getPriceHistoryHandler, map[string]interface{}{"product_id": "9"}, "history"},
+		{"get_audit_log", getAuditLogHandler, map[string]interface{}{"since": "2999-01-01T00:00:00Z"}, "entries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req mcp.CallToolRequest
+			req.Params.Arguments = tt.args
+			res, err := tt.handler(context.Background(), req)
+			if err != nil {
+				t.Fatalf("handler returned an error: %v", err)
+			}
+			if res.IsError {
+				t.Fatalf("got error result %v", decodeResult(t, res))
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &fields); err != nil {
+				t.Fatalf("result is not JSON: %v", err)
+			}
+			if got := string(fields[tt.field]); got != "[]" {
+				t.Errorf("%s is %s, want []", tt.field, got)
+			}
+			if _, ok := fields["schema_version"]; !ok {
+				t.Error("result has no schema_version")
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultMaxResultSize is the default cap on the serialized size of a tool
+// result in bytes
+const defaultMaxResultSize = 1 << 20
+
+// resultPreviewSize is how much of an oversized result's text is kept
+const resultPreviewSize = 512
+
+// maxResultSize caps the serialized size of every tool result; 0 disables
+// the cap
+var maxResultSize = defaultMaxResultSize
+
+// resultSizeMiddleware replaces results whose serialized size exceeds
+// maxResultSize with a small error result carrying the start of the text, so
+// a runaway tool cannot flood the stdio pipe
+func resultSizeMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, req)
+		if err != nil || result == nil || maxResultSize <= 0 {
+			return result, err
+		}
+
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		if len(encoded) <= maxResultSize {
+			return result, nil
+		}
+
+		log.Printf("Result of %s is %d bytes, over the %d byte limit; truncated", req.Params.Name, len(encoded), maxResultSize)
+		return jsonError(map[string]interface{}{
+			"success":         false,
+			"error":           "Result too large",
+			"message":         localize(ctx, "result_too_large", req.Params.Name, len(encoded), maxResultSize),
+			"truncated":       true,
+			"result_size":     len(encoded),
+			"max_result_size": maxResultSize,
+			"preview":         resultPreview(result),
+		}), nil
+	}
+}
+
+// resultPreview returns the first resultPreviewSize bytes of the result's
+// first text content, cut on a character boundary
+func resultPreview(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+		preview := text.Text
+		if len(preview) <= resultPreviewSize {
+			return preview
+		}
+		preview = preview[:resultPreviewSize]
+		for len(preview) > 0 && !utf8.ValidString(preview) {
+			preview = preview[:len(preview)-1]
+		}
+		return preview
+	}
+	return ""
+}
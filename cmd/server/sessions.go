@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionSweepInterval is how often idle sessions are looked for
+const sessionSweepInterval = time.Minute
+
+// sessionState is the per-session data kept in memory
+type sessionState struct {
+	Points   int
+	Wishlist []WishlistItem
+	// LastAccess is when a tool call last used the session
+	LastAccess time.Time
+}
+
+// sessionStore holds the state of every session by session ID
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionState
+	// ttl is how long a session may sit unused before it is evicted, with
+	// its points and wishlist; 0 keeps sessions forever
+	ttl time.Duration
+}
+
+var sessions = &sessionStore{sessions: make(map[string]*sessionState), ttl: 24 * time.Hour}
+
+// with runs fn with the state of the given session, creating it if needed,
+// and marks the session as used. fn runs under the store lock and must not
+// call back into the store.
+func (s *sessionStore) with(id string, fn func(state *sessionState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.sessions[id]
+	if !ok {
+		state = &sessionState{}
+		s.sessions[id] = state
+	}
+	state.LastAccess = time.Now()
+	fn(state)
+}
+
+// count returns the number of sessions held in memory
+func (s *sessionStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.sessions)
+}
+
+// evictIdle removes the sessions unused for longer than the TTL and returns
+// how many were removed
+func (s *sessionStore) evictIdle(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ttl <= 0 {
+		return 0
+	}
+	evicted := 0
+	for id, state := range s.sessions {
+		if now.Sub(state.LastAccess) > s.ttl {
+			delete(s.sessions, id)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// startSessionJanitor evicts idle sessions every sessionSweepInterval for
+// the life of the process, so a long running server does not keep every
+// session it has seen. It does nothing when sessions never expire.
+func startSessionJanitor() {
+	if sessions.ttl <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(sessionSweepInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if n := sessions.evictIdle(now); n > 0 {
+				log.Printf("Evicted %d idle session(s), %d left", n, sessions.count())
+			}
+		}
+	}()
+}
+
+// sessionID returns the session a stateful tool call belongs to: the explicit
+// session_id argument if given, otherwise the MCP client session
+func sessionID(ctx context.Context, args map[string]interface{}) string {
+	if id, ok := args["session_id"].(string); ok && id != "" {
+		return id
+	}
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return "default"
+}
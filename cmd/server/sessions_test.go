@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionsEvictIdle(t *testing.T) {
+	s := &sessionStore{sessions: make(map[string]*sessionState), ttl: time.Hour}
+	s.with("idle", func(state *sessionState) { state.Points = 10 })
+	s.with("active", func(state *sessionState) {})
+
+	// Backdate the idle session past the TTL
+	s.sessions["idle"].LastAccess = time.Now().Add(-2 * time.Hour)
+
+	if n := s.evictIdle(time.Now()); n != 1 {
+		t.Errorf("evicted %d sessions, want 1", n)
+	}
+	if n := s.count(); n != 1 {
+		t.Errorf("%d sessions left, want 1", n)
+	}
+	if _, ok := s.sessions["active"]; !ok {
+		t.Error("the active session was evicted")
+	}
+
+	// An evicted session comes back empty
+	s.with("idle", func(state *sessionState) {
+		if state.Points != 0 {
+			t.Errorf("evicted session kept %d points", state.Points)
+		}
+	})
+}
+
+func TestSessionsWithMarksAccess(t *testing.T) {
+	s := &sessionStore{sessions: make(map[string]*sessionState), ttl: time.Hour}
+	s.with("a", func(state *sessionState) {})
+	s.sessions["a"].LastAccess = time.Now().Add(-2 * time.Hour)
+
+	// Using the session again resets its idle time
+	s.with("a", func(state *sessionState) {})
+	if n := s.evictIdle(time.Now()); n != 0 {
+		t.Errorf("evicted %d sessions right after use, want 0", n)
+	}
+}
+
+func TestSessionsZeroTTLKeepsSessions(t *testing.T) {
+	s := &sessionStore{sessions: make(map[string]*sessionState)}
+	s.with("a", func(state *sessionState) {})
+	if n := s.evictIdle(time.Now().Add(365 * 24 * time.Hour)); n != 0 || s.count() != 1 {
+		t.Errorf("evicted %d sessions with no TTL, want 0", n)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// shippingBracket is a shipping rate for orders up to MaxWeight kilograms
+type shippingBracket struct {
+	Name      string
+	MaxWeight float64
+	Cost      float64
+}
+
+// shippingBrackets must be sorted by MaxWeight; the last bracket covers any
+// heavier order
+var shippingBrackets = []shippingBracket{
+	{Name: "light", MaxWeight: 1, Cost: 5},
+	{Name: "standard", MaxWeight: 5, Cost: 10},
+	{Name: "heavy", MaxWeight: 20, Cost: 25},
+	{Name: "freight", MaxWeight: 0, Cost: 60},
+}
+
+// freeShippingThreshold is the subtotal from which shipping is free
+const freeShippingThreshold = 2000.0
+
+// defaultShippingZone is the destination of orders that do not name one
+const defaultShippingZone = "domestic"
+
+// shippingZones multiplies the bracket cost by destination zone. Zone names
+// are lower case; STORE_SHIPPING_ZONES replaces the whole table.
+var shippingZones = map[string]float64{
+	"domestic": 1,
+	"asia":     1.5,
+	"europe":   2,
+	"americas": 2,
+	"oceania":  2,
+}
+
+// parseShippingZones parses a list such as "domestic=1,asia=1.5" into
+// multipliers by zone name
+func parseShippingZones(spec string) (map[string]float64, error) {
+	zones := make(map[string]float64)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not zone=multiplier", entry)
+		}
+		multiplier, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || multiplier <= 0 {
+			return nil, fmt.Errorf("%q: multiplier must be a positive number", entry)
+		}
+		zones[name] = multiplier
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("no zones given")
+	}
+	return zones, nil
+}
+
+// shippingZoneNames returns the configured zones in name order
+func shippingZoneNames() []string {
+	names := make([]string, 0, len(shippingZones))
+	for name := range shippingZones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bracketForWeight returns the shipping bracket covering the given weight
+func bracketForWeight(weight float64) shippingBracket {
+	for _, b := range shippingBrackets[:len(shippingBrackets)-1] {
+		if weight <= b.MaxWeight {
+			return b
+		}
+	}
+	return shippingBrackets[len(shippingBrackets)-1]
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "items": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "properties": {
+	          "product_id": {"type": "string"},
+	          "quantity": {"type": ["integer", "string"]}
+	        },
+	        "required": ["product_id", "quantity"]
+	      }
+	    },
+	    "subtotal": {"type": "number"},
+	    "destination": {"type": "string"}
+	  }
+	}
+*/
+func shippingCostHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+
+	var weight, subtotal float64
+	items, hasItems := args["items"].([]interface{})
+	if hasItems && len(items) > 0 {
+		lines, errResult := parseItems(ctx, items)
+		if errResult != nil {
+			return errResult, nil
+		}
+		for _, line := range lines {
+			weight += line.Product.Weight * float64(line.Quantity)
+			subtotal += line.Product.Price * float64(line.Quantity)
+		}
+		weight = math.Round(weight*1000) / 1000
+		subtotal = roundMoney(subtotal)
+	} else if s, ok := args["subtotal"].(float64); ok {
+		// Without items the weight is unknown, so the lightest bracket applies
+		subtotal = s
+	} else {
+		return jsonError(map[string]interface{}{
+			"success": false,
+			"error":   "Either items or subtotal is required",
+		}), nil
+	}
+
+	zone := defaultShippingZone
+	if destination, ok := args["destination"].(string); ok && strings.TrimSpace(destination) != "" {
+		zone = strings.ToLower(strings.TrimSpace(destination))
+	}
+	multiplier, ok := shippingZones[zone]
+	if !ok {
+		zones := shippingZoneNames()
+		return jsonError(map[string]interface{}{
+			"success":     false,
+			"error":       "Unknown destination",
+			"destination": zone,
+			"zones":       zones,
+			"message":     localize(ctx, "shipping_unknown_zone", zone, strings.Join(zones, ", ")),
+		}), nil
+	}
+
+	// The zone scales the weight bracket's base cost
+	bracket := bracketForWeight(weight)
+	freeShipping := subtotal >= freeShippingThreshold
+	cost := roundMoney(bracket.Cost * multiplier)
+	if freeShipping {
+		cost = 0
+	}
+
+	message := localize(ctx, "shipping_cost", weight, bracket.Name, zone, money(cost))
+	if freeShipping {
+		message = localize(ctx, "shipping_free", money(freeShippingThreshold))
+	}
+
+	return jsonResult(map[string]interface{}{
+		"success":                 true,
+		"tax_inclusive":           pricesIncludeTax,
+		"total_weight":            weight,
+		"subtotal":                subtotal,
+		"bracket":                 bracket.Name,
+		"bracket_cost":            bracket.Cost,
+		"zone":                    zone,
+		"zone_multiplier":         multiplier,
+		"shipping_cost":           cost,
+		"free_shipping":           freeShipping,
+		"free_shipping_threshold": freeShippingThreshold,
+		"message":                 message,
+	}), nil
+}
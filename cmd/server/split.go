@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxSplitPeople bounds how many ways a bill can be split
+	maxSplitPeople = 100
+	// shareScale turns shares such as 1.5 into whole weights; shares are
+	// used to three decimal places
+	shareScale = 1000
+)
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "total_price": {"type": "number"},
+	    "people": {"type": "integer"},
+	    "shares": {"type": "array", "items": {"type": "number"}}
+	  },
+	  "required": ["total_price"]
+	}
+*/
+func splitBillHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("no arguments provided")
+	}
+	totalPrice, ok := args["total_price"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("missing total_price")
+	}
+	if totalPrice < 0 {
+		return jsonError(map[string]interface{}{
+			"success":     false,
+			"error":       "total_price cannot be negative",
+			"total_price": totalPrice,
+		}), nil
+	}
+
+	// Without shares everyone pays an equal share
+	rawShares, hasShares := args["shares"].([]interface{})
+	people := len(rawShares)
+	if p, ok := args["people"].(float64); ok {
+		if p != float64(int(p)) || p < 1 || p > maxSplitPeople {
+			return jsonError(map[string]interface{}{
+				"success":    false,
+				"error":      fmt.Sprintf("people must be an integer from 1 to %d", maxSplitPeople),
+				"people":     p,
+				"max_people": maxSplitPeople,
+			}), nil
+		}
+		if hasShares && int(p) != len(rawShares) {
+			return jsonError(map[string]interface{}{
+				"success": false,
+				"error":   "people does not match the number of shares",
+				"people":  int(p),
+				"shares":  len(rawShares),
+				"message": localize(ctx, "split_share_count", int(p), len(rawShares)),
+			}), nil
+		}
+		people = int(p)
+	}
+	if people < 1 || people > maxSplitPeople {
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      fmt.Sprintf("Give people or between 1 and %d shares", maxSplitPeople),
+			"max_people": maxSplitPeople,
+		}), nil
+	}
+
+	shares := make([]float64, people)
+	weights := make([]int64, people)
+	for i := range shares {
+		shares[i] = 1
+		if hasShares {
+			share, ok := rawShares[i].(float64)
+			if !ok || math.Round(share*shareScale) < 1 {
+				return jsonError(map[string]interface{}{
+					"success": false,
+					"error":   "shares must be positive numbers",
+					"index":   i,
+					"share":   rawShares[i],
+				}), nil
+			}
+			shares[i] = share
+		}
+		weights[i] = int64(math.Round(shares[i] * shareScale))
+	}
+
+	// Whole minor units are handed out by largest remainder, earlier people
+	// first on ties, so the parts add up to the total exactly
+	total := roundMoney(totalPrice)
+	units := allocateUnits(toMinorUnits(total), weights)
+
+	parts := make([]map[string]interface{}, people)
+	lowest, highest := units[0], units[0]
+	for i, u := range units {
+		parts[i] = map[string]interface{}{
+			"person": i + 1,
+			"share":  shares[i],
+			"amount": fromMinorUnits(u),
+		}
+		lowest, highest = min(lowest, u), max(highest, u)
+	}
+
+	result := map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"currency":      storeCurrency,
+		"total_price":   total,
+		"people":        people,
+		"even":          !hasShares,
+		"parts":         parts,
+	}
+	if lowest == highest {
+		result["message"] = localize(ctx, "bill_split_equal", money(total), people, money(fromMinorUnits(lowest)))
+	} else {
+		result["message"] = localize(ctx, "bill_split", money(total), people, money(fromMinorUnits(lowest)), money(fromMinorUnits(highest)))
+	}
+	return jsonResult(result), nil
+}
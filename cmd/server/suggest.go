@@ -0,0 +1,100 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the number of close matches reported for an unknown ID
+const maxSuggestions = 3
+
+// maxSuggestionDistance is the largest edit distance still considered a
+// plausible typo
+const maxSuggestionDistance = 2
+
+// ProductSuggestion is a product that closely matches an unknown ID
+type ProductSuggestion struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	MatchedOn   string `json:"matched_on"`
+}
+
+// suggestProducts returns the products whose ID, name or alias is closest to
+// the query, best match first. Exact, prefix and substring matches rank ahead
+// of typos within maxSuggestionDistance edits.
+func suggestProducts(query string) []ProductSuggestion {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+
+	type candidate struct {
+		suggestion ProductSuggestion
+		score      int
+	}
+	var candidates []candidate
+	for _, p := range catalog.List() {
+		best, matched := -1, ""
+		names := append([]string{p.ID, p.Name}, productAliases[p.ID]...)
+		for _, name := range names {
+			if score := matchScore(q, strings.ToLower(name)); score >= 0 && (best < 0 || score < best) {
+				best, matched = score, name
+			}
+		}
+		if best >= 0 {
+			candidates = append(candidates, candidate{
+				suggestion: ProductSuggestion{ProductID: p.ID, ProductName: p.Name, MatchedOn: matched},
+				score:      best,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score < candidates[j].score })
+	if len(candidates) > maxSuggestions {
+		candidates = candidates[:maxSuggestions]
+	}
+	suggestions := make([]ProductSuggestion, 0, len(candidates))
+	for _, c := range candidates {
+		suggestions = append(suggestions, c.suggestion)
+	}
+	return suggestions
+}
+
+// matchScore rates how well name matches the query, lower is better, or -1
+// when it does not match at all
+func matchScore(query, name string) int {
+	switch {
+	case query == name:
+		return 0
+	case strings.HasPrefix(name, query) || strings.HasPrefix(query, name):
+		return 1
+	case strings.Contains(name, query) || strings.Contains(query, name):
+		return 2
+	}
+	if d := levenshtein(query, name); d <= maxSuggestionDistance && d < len([]rune(name)) {
+		return 2 + d
+	}
+	return -1
+}
+
+// levenshtein returns the edit distance between two strings, counted in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,41 @@
+package main
+
+var (
+	// pricesIncludeTax reports whether catalog prices, and so every price the
+	// tools return, already include tax
+	pricesIncludeTax = false
+	// taxRate is the sales tax rate used to derive net and gross amounts,
+	// e.g. 0.05 for 5%
+	taxRate = 0.0
+)
+
+// taxBreakdown splits an amount expressed in the store's pricing convention
+// into its net amount, the tax at the given rate and the gross amount
+func taxBreakdown(amount, rate float64) (net, tax, gross float64) {
+	if pricesIncludeTax {
+		gross = roundMoney(amount)
+		net = roundMoney(amount / (1 + rate))
+		return net, roundMoney(gross - net), gross
+	}
+	net = roundMoney(amount)
+	tax = roundMoney(amount * rate)
+	return net, tax, roundMoney(net + tax)
+}
+
+// allocateTax splits an order-level tax across lines in proportion to their
+// amounts, in whole minor units (cents for USD), so the line taxes always sum
+// exactly to the order tax.
+func allocateTax(orderTax float64, amounts []float64) []float64 {
+	taxes := make([]float64, len(amounts))
+
+	weights := make([]int64, len(amounts))
+	for i, amount := range amounts {
+		weights[i] = toMinorUnits(amount)
+	}
+
+	units := allocateUnits(toMinorUnits(orderTax), weights)
+	for i, u := range units {
+		taxes[i] = fromMinorUnits(u)
+	}
+	return taxes
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllocateUnits(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{"even split", 300, []int64{1, 1, 1}, []int64{100, 100, 100}},
+		{"leftover goes to the largest remainder", 100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{"proportional", 10, []int64{199, 299, 99}, []int64{3, 5, 2}},
+		{"ties go to the earlier weight", 1, []int64{5, 5}, []int64{1, 0}},
+		{"zero weights", 10, []int64{0, 0}, []int64{0, 0}},
+		{"nothing to split", 0, []int64{3, 7}, []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocateUnits(tt.total, tt.weights)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocateUnits(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerLineTaxReconciles(t *testing.T) {
+	useCatalog(t, []Product{
+		{ID: "1", Name: "Pen", Price: 0.99, Stock: 1000},
+		{ID: "2", Name: "Notebook", Price: 1.99, Stock: 1000},
+		{ID: "3", Name: "Stapler", Price: 2.99, Stock: 1000},
+	})
+
+	tests := []struct {
+		name  string
+		items []interface{}
+		rate  float64
+	}{
+		{"three odd lines", itemsArg("1", 1, "2", 1, "3", 1), 0.0725},
+		{"line taxes would round up", itemsArg("1", 3, "2", 3, "3", 3), 0.0825},
+		{"many small lines", itemsArg("1", 1, "1", 1, "1", 1, "1", 1, "1", 1, "1", 1, "1", 1), 0.05},
+		{"zero rate", itemsArg("1", 2, "3", 1), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callTool(t, calculateTotalHandler, map[string]interface{}{
+				"items":        tt.items,
+				"tax_rate":     tt.rate,
+				"per_line_tax": true,
+			})
+			if result["success"] != true {
+				t.Fatalf("got %v, want success", result)
+			}
+
+			var lineUnits int64
+			for _, item := range result["items"].([]interface{}) {
+				lineTax, ok := item.(map[string]interface{})["tax"].(float64)
+				if !ok {
+					t.Fatalf("item %v has no tax", item)
+				}
+				lineUnits += toMinorUnits(lineTax)
+			}
+			if orderUnits := toMinorUnits(result["tax"].(float64)); lineUnits != orderUnits {
+				t.Errorf("line taxes add up to %d cents, order tax is %d cents", lineUnits, orderUnits)
+			}
+		})
+	}
+}
+
+func TestLineTaxOnlyWhenAsked(t *testing.T) {
+	useCatalog(t, testProducts)
+
+	result := callTool(t, calculateTotalHandler, map[string]interface{}{"items": itemsArg("1", 1), "tax_rate": 0.05})
+	item := result["items"].([]interface{})[0].(map[string]interface{})
+	if _, ok := item["tax"]; ok {
+		t.Errorf("item %v has a tax without per_line_tax", item)
+	}
+}
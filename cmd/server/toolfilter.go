@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+)
+
+// enabledTools is the set of tool names that may be registered; nil enables
+// every tool. Admin tools still require admin mode.
+var enabledTools map[string]bool
+
+// registeredTools lists the tools addTool registered, in registration order
+var registeredTools []string
+
+// parseToolList parses a comma separated list of tool names. An empty list
+// enables every tool.
+func parseToolList(list string) map[string]bool {
+	tools := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			tools[name] = true
+		}
+	}
+	if len(tools) == 0 {
+		return nil
+	}
+	return tools
+}
+
+// toolEnabled reports whether the tool with the given name may be registered
+func toolEnabled(name string) bool {
+	return enabledTools == nil || enabledTools[name]
+}
+
+// logEnabledTools logs the registered tools and any enabled tool name that
+// did not match a tool, which is usually a typo
+func logEnabledTools() {
+	log.Printf("Enabled tools: %s", strings.Join(registeredTools, ", "))
+
+	registered := make(map[string]bool, len(registeredTools))
+	for _, name := range registeredTools {
+		registered[name] = true
+	}
+	var unknown []string
+	for name := range enabledTools {
+		if !registered[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		log.Printf("Ignoring unknown or unavailable tools in the enabled tool list: %s", strings.Join(unknown, ", "))
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "tool_name": {"type": "string"}
+	  },
+	  "required": ["tool_name"]
+	}
+*/
+func getToolSchemaHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	name, ok := args["tool_name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("tool_name is not a string")
+	}
+
+	schema, ok := toolSchemas.Load(name)
+	if !ok {
+		// Only registered tools are known, so disabled tools are not found
+		available := make([]string, 0, len(registeredTools))
+		available = append(available, registeredTools...)
+		sort.Strings(available)
+		return jsonError(map[string]interface{}{
+			"success":         false,
+			"error":           "Tool not found",
+			"tool_name":       name,
+			"available_tools": available,
+			"message":         localize(ctx, "tool_not_found", name),
+		}), nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"success":      true,
+		"tool_name":    name,
+		"input_schema": schema.(mcp.ToolInputSchema),
+		"message":      localize(ctx, "tool_schema", name),
+	}), nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// totalTokenTTL is how long a total_token from calculate_total stays valid
+var totalTokenTTL = 10 * time.Minute
+
+// totalTokenKey signs total tokens. It is generated at startup, so tokens do
+// not survive a restart.
+var totalTokenKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("failed to generate total token key: %v", err))
+	}
+	return key
+}()
+
+var (
+	// errTotalTokenInvalid means the token is malformed or was not issued by
+	// this server
+	errTotalTokenInvalid = errors.New("total token is invalid")
+	// errTotalTokenExpired means the token was valid but is too old
+	errTotalTokenExpired = errors.New("total token has expired")
+)
+
+// issueTotalToken returns an opaque token proving that calculate_total
+// computed the given total. It holds the total in minor units of the store
+// currency and an expiry time, signed with HMAC-SHA256.
+func issueTotalToken(total float64) string {
+	payload := fmt.Sprintf("%d.%d", toMinorUnits(total), time.Now().Add(totalTokenTTL).Unix())
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + signTotalToken(encoded)
+}
+
+// verifyTotalToken checks the token's signature and expiry and returns the
+// total it was issued for
+func verifyTotalToken(token string) (float64, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signTotalToken(encoded))) {
+		return 0, errTotalTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, errTotalTokenInvalid
+	}
+
+	var units, expires int64
+	if _, err := fmt.Sscanf(string(payload), "%d.%d", &units, &expires); err != nil {
+		return 0, errTotalTokenInvalid
+	}
+	if time.Now().Unix() > expires {
+		return 0, errTotalTokenExpired
+	}
+	return fromMinorUnits(units), nil
+}
+
+func signTotalToken(encoded string) string {
+	mac := hmac.New(sha256.New, totalTokenKey)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
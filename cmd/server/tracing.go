@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// traceIDFromRequest returns the trace_id the client passed in the request's
+// _meta, if any
+func traceIDFromRequest(req mcp.CallToolRequest) string {
+	if req.Params.Meta == nil {
+		return ""
+	}
+	traceID, _ := req.Params.Meta.AdditionalFields["trace_id"].(string)
+	return traceID
+}
+
+// tracingMiddleware logs every tool call with its trace ID and echoes the ID
+// back in the result's _meta so the client can correlate the two. A call that
+// panics is still logged on its way out to the recovery middleware.
+func tracingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		traceID := traceIDFromRequest(req)
+		start := time.Now()
+		panicked := true
+		defer func() {
+			if panicked {
+				log.Printf("trace=%s tool=%s duration=%v result=panic", traceID, req.Params.Name, time.Since(start))
+			}
+		}()
+		result, err := next(ctx, req)
+		panicked = false
+		elapsed := time.Since(start)
+
+		switch {
+		case err != nil:
+			log.Printf("trace=%s tool=%s duration=%v error=%v", traceID, req.Params.Name, elapsed, err)
+		case result != nil && result.IsError:
+			log.Printf("trace=%s tool=%s duration=%v result=error", traceID, req.Params.Name, elapsed)
+		default:
+			log.Printf("trace=%s tool=%s duration=%v result=ok", traceID, req.Params.Name, elapsed)
+		}
+
+		if traceID != "" && result != nil {
+			if result.Meta == nil {
+				result.Meta = make(map[string]any)
+			}
+			result.Meta["trace_id"] = traceID
+		}
+		return result, err
+	}
+}
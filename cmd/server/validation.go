@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolSchemas maps a tool name to its mcp.ToolInputSchema. mcp-go does not
+// expose registered tools, so addTool records each schema as it registers it.
+var toolSchemas sync.Map
+
+// addTool registers a tool with the server and records its input schema for
+// validationMiddleware. Tools left out of the enabled tool list are skipped.
+func addTool(s *server.MCPServer, tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if !toolEnabled(tool.Name) {
+		return
+	}
+	registeredTools = append(registeredTools, tool.Name)
+	toolSchemas.Store(tool.Name, tool.InputSchema)
+	s.AddTool(tool, handler)
+}
+
+// schemaViolation describes a single argument that does not match the schema
+type schemaViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// validationMiddleware checks the arguments of every call against the tool's
+// input schema and returns a uniform validation error before the handler runs
+func validationMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		schema, ok := toolSchemas.Load(req.Params.Name)
+		if !ok {
+			return next(ctx, req)
+		}
+
+		args := req.GetArguments()
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		inputSchema := schema.(mcp.ToolInputSchema)
+		violations := validateObject("", args, inputSchema.Properties, inputSchema.Required)
+		if len(violations) == 0 {
+			return next(ctx, req)
+		}
+
+		messages := make([]string, 0, len(violations))
+		for _, v := range violations {
+			messages = append(messages, fmt.Sprintf("%s: %s", v.Field, v.Message))
+		}
+		return jsonError(map[string]interface{}{
+			"success":    false,
+			"error":      "Invalid arguments",
+			"tool":       req.Params.Name,
+			"violations": violations,
+			"message":    localize(ctx, "invalid_arguments", req.Params.Name, strings.Join(messages, "; ")),
+		}), nil
+	}
+}
+
+// validateObject checks the required fields and the declared properties of
+// an object. Properties that are not declared are left to the handler.
+func validateObject(path string, obj map[string]interface{}, properties map[string]any, required []string) []schemaViolation {
+	var violations []schemaViolation
+	for _, name := range required {
+		if _, ok := obj[name]; !ok {
+			violations = append(violations, schemaViolation{Field: joinPath(path, name), Message: "is required"})
+		}
+	}
+
+	// Walk the arguments in name order so violations are reported consistently
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propSchema, ok := properties[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		violations = append(violations, validateValue(joinPath(path, name), obj[name], propSchema)...)
+	}
+	return violations
+}
+
+// validateValue checks a value against the type, enum, items and properties
+// keywords of its schema
+func validateValue(path string, value interface{}, schema map[string]any) []schemaViolation {
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !matchesAnyType(value, types) {
+		return []schemaViolation{{
+			Field:   path,
+			Message: fmt.Sprintf("expected %s, got %s", strings.Join(types, " or "), jsonTypeName(value)),
+		}}
+	}
+
+	if enum, ok := schema["enum"].([]string); ok && len(enum) > 0 {
+		s, _ := value.(string)
+		found := false
+		for _, allowed := range enum {
+			if s == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []schemaViolation{{
+				Field:   path,
+				Message: fmt.Sprintf("must be one of %s", strings.Join(enum, ", ")),
+			}}
+		}
+	}
+
+	var violations []schemaViolation
+	switch v := value.(type) {
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				violations = append(violations, validateValue(fmt.Sprintf("%s[%d]", path, i), item, items)...)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]string)
+		violations = append(violations, validateObject(path, v, properties, required)...)
+	}
+	return violations
+}
+
+// schemaTypes returns the allowed types of a schema, which may be a single
+// type name or a list of them
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesType reports whether a decoded JSON value has the given schema type
+func matchesType(value interface{}, t string) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// jsonTypeName names the JSON type of a decoded value for error messages
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// webhookAttempts is how many times an order is posted before giving up
+	webhookAttempts = 3
+	// webhookRetryDelay is the wait before the first retry; it doubles after
+	// every failed attempt
+	webhookRetryDelay = time.Second
+)
+
+// orderWebhookURL receives a POST with the order JSON after every placed
+// order; empty disables the webhook
+var orderWebhookURL = ""
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// validateWebhookURL checks that a webhook URL is an absolute http(s) URL
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL")
+	}
+	return nil
+}
+
+// notifyOrderWebhook posts the order to orderWebhookURL in the background,
+// retrying failed attempts. Failures are logged and never affect the order.
+func notifyOrderWebhook(order *Order) {
+	if orderWebhookURL == "" {
+		return
+	}
+
+	// Encode now so the goroutine does not share the stored order
+	body, err := json.Marshal(order)
+	if err != nil {
+		log.Printf("Order webhook for %s not sent: %v", order.ID, err)
+		return
+	}
+
+	go func(url, orderID string) {
+		delay := webhookRetryDelay
+		for attempt := 1; ; attempt++ {
+			err := postWebhook(url, orderID, body)
+			if err == nil {
+				return
+			}
+			if attempt == webhookAttempts {
+				log.Printf("Order webhook for %s failed after %d attempts: %v", orderID, attempt, err)
+				return
+			}
+			log.Printf("Order webhook for %s failed (attempt %d), retrying in %v: %v", orderID, attempt, delay, err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}(orderWebhookURL, order.ID)
+}
+
+// postWebhook sends one webhook request; any non-2xx status is an error
+func postWebhook(url, orderID string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Order-ID", orderID)
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WishlistItem is a product a shopper saved for later, with its price at
+// the time it was saved
+type WishlistItem struct {
+	ProductID  string    `json:"product_id"`
+	SavedPrice float64   `json:"saved_price"`
+	AddedAt    time.Time `json:"added_at"`
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "product_id": {"type": "string"},
+	    "session_id": {"type": "string"}
+	  },
+	  "required": ["product_id"]
+	}
+*/
+func wishlistAddHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	if args == nil {
+		return nil, fmt.Errorf("invalid arguments")
+	}
+	productID, ok := args["product_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("product_id is not a string")
+	}
+
+	product, ok := catalog.Get(productID)
+	if !ok {
+		errorResult := map[string]interface{}{
+			"success":    false,
+			"error":      "Product not found",
+			"product_id": productID,
+		}
+		if suggestions := suggestProducts(productID); len(suggestions) > 0 {
+			errorResult["suggestions"] = suggestions
+			errorResult["message"] = localize(ctx, "product_suggestions", productID, suggestions[0].ProductName, suggestions[0].ProductID)
+		}
+		return jsonError(errorResult), nil
+	}
+
+	id := sessionID(ctx, args)
+	alreadySaved := false
+	var count int
+	sessions.with(id, func(state *sessionState) {
+		for _, item := range state.Wishlist {
+			if item.ProductID == productID {
+				alreadySaved = true
+				break
+			}
+		}
+		if !alreadySaved {
+			state.Wishlist = append(state.Wishlist, WishlistItem{
+				ProductID:  productID,
+				SavedPrice: roundMoney(product.Price),
+				AddedAt:    time.Now(),
+			})
+		}
+		count = len(state.Wishlist)
+	})
+
+	message := localize(ctx, "wishlist_added", product.Name, count)
+	if alreadySaved {
+		message = localize(ctx, "wishlist_already_saved", product.Name, count)
+	}
+	return jsonResult(map[string]interface{}{
+		"success":       true,
+		"session_id":    id,
+		"product_id":    product.ID,
+		"product_name":  product.Name,
+		"already_saved": alreadySaved,
+		"item_count":    count,
+		"message":       message,
+	}), nil
+}
+
+/*
+	{
+	  "type": "object",
+	  "properties": {
+	    "session_id": {"type": "string"}
+	  }
+	}
+*/
+func wishlistListHandler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+
+	id := sessionID(ctx, args)
+	var saved []WishlistItem
+	sessions.with(id, func(state *sessionState) {
+		saved = append(saved, state.Wishlist...)
+	})
+
+	// Compare current catalog prices with the prices when each item was saved
+	items := make([]map[string]interface{}, 0, len(saved))
+	drops := 0
+	for _, item := range saved {
+		entry := map[string]interface{}{
+			"product_id":  item.ProductID,
+			"added_at":    item.AddedAt,
+			"saved_price": item.SavedPrice,
+		}
+		if product, ok := catalog.Get(item.ProductID); ok {
+			price := roundMoney(product.Price)
+			entry["product_name"] = product.Name
+			entry["price"] = price
+			entry["available"] = true
+			entry["price_changes_since_saved"] = priceHistory.changesSince(item.ProductID, item.AddedAt)
+			entry["price_dropped"] = price < item.SavedPrice
+			if price < item.SavedPrice {
+				entry["price_drop"] = roundMoney(item.SavedPrice - price)
+				drops++
+			}
+		} else {
+			// The product was removed from the catalog after it was saved
+			entry["available"] = false
+		}
+		items = append(items, entry)
+	}
+
+	message := localize(ctx, "wishlist_items", len(items))
+	if drops > 0 {
+		message = localize(ctx, "wishlist_price_drops", len(items), drops)
+	}
+	return jsonResult(map[string]interface{}{
+		"success":       true,
+		"tax_inclusive": pricesIncludeTax,
+		"session_id":    id,
+		"items":         items,
+		"item_count":    len(items),
+		"price_drops":   drops,
+		"message":       message,
+	}), nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestWishlistAddSkipsDuplicates(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &sessions, &sessionStore{sessions: make(map[string]*sessionState)})
+
+	first := callTool(t, wishlistAddHandler, map[string]interface{}{"product_id": "1", "session_id": "a"})
+	if first["success"] != true || first["already_saved"] != false || first["item_count"] != 1.0 {
+		t.Fatalf("first add got %v, want one item saved", first)
+	}
+	again := callTool(t, wishlistAddHandler, map[string]interface{}{"product_id": "1", "session_id": "a"})
+	if again["already_saved"] != true || again["item_count"] != 1.0 {
+		t.Errorf("second add got %v, want already_saved with one item", again)
+	}
+
+	unknown := callTool(t, wishlistAddHandler, map[string]interface{}{"product_id": "99", "session_id": "a"})
+	if unknown["error"] != "Product not found" {
+		t.Errorf("unknown product got %v, want Product not found", unknown)
+	}
+}
+
+func TestWishlistListShowsCurrentPrices(t *testing.T) {
+	useCatalog(t, testProducts)
+	setVar(t, &sessions, &sessionStore{sessions: make(map[string]*sessionState)})
+
+	for _, id := range []string{"1", "2"} {
+		callTool(t, wishlistAddHandler, map[string]interface{}{"product_id": id, "session_id": "a"})
+	}
+	// Another session's wishlist is kept apart
+	callTool(t, wishlistAddHandler, map[string]interface{}{"product_id": "3", "session_id": "b"})
+
+	if _, err := catalog.UpdatePrice("1", 900); err != nil {
+		t.Fatal(err)
+	}
+	catalog.Remove("2")
+
+	result := callTool(t, wishlistListHandler, map[string]interface{}{"session_id": "a"})
+	items, _ := result["items"].([]interface{})
+	if len(items) != 2 {
+		t.Fatalf("got %v, want two items", result)
+	}
+	laptop := items[0].(map[string]interface{})
+	if laptop["product_id"] != "1" || laptop["price"] != 900.0 || laptop["available"] != true {
+		t.Errorf("first item is %v, want the laptop at its new price 900", laptop)
+	}
+	removed := items[1].(map[string]interface{})
+	if removed["product_id"] != "2" || removed["available"] != false {
+		t.Errorf("second item is %v, want the removed smartphone marked unavailable", removed)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/davidleitw/mcp-store-example
 go 1.24.0
 
 require (
+	github.com/chzyer/readline v1.5.1
 	github.com/mark3labs/mcp-go v0.30.0
 	github.com/sashabaranov/go-openai v1.40.1
 )
@@ -11,4 +12,5 @@ require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/spf13/cast v1.7.1 // indirect
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
+	golang.org/x/sys v0.0.0-20220310020820-b874c991c1a5 // indirect
 )